@@ -0,0 +1,346 @@
+package patterns
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func BatchDemo() {
+	fmt.Println("Batch Pattern Demo...")
+	ctx := context.Background()
+
+	events := func() <-chan string {
+		ch := make(chan string)
+		go func() {
+			defer close(ch)
+			for i := 1; i <= 9; i++ {
+				user := []string{"alice", "bob", "carol"}[i%3]
+				ch <- fmt.Sprintf("%s:%d", user, i)
+				time.Sleep(30 * time.Millisecond)
+			}
+		}()
+
+		return ch
+	}
+
+	fmt.Println("batches of 4, or 100ms:")
+	for batch := range Batch(ctx, events(), 4, 100*time.Millisecond) {
+		fmt.Println(batch)
+	}
+
+	fmt.Println("tumbling windows of 100ms:")
+	for window := range TumblingWindow(ctx, events(), 100*time.Millisecond) {
+		fmt.Println(window)
+	}
+
+	fmt.Println("sliding windows of 100ms, every 50ms:")
+	for window := range SlidingWindow(ctx, events(), 100*time.Millisecond, 50*time.Millisecond) {
+		fmt.Println(window)
+	}
+
+	fmt.Println("batches of 2 per user, or 200ms:")
+	user := func(e string) string { return e[:len(e)-2] }
+	for batch := range BatchByKey(ctx, events(), user, 2, 200*time.Millisecond) {
+		fmt.Println(batch.Key, batch.Values)
+	}
+}
+
+// Batch groups the values of `in` in batches of up to `size` values, emitting
+// a batch when it's full or `maxLatency` after its first value, whatever
+// happens first, e.g. to turn the output of a Funnel into bulk writes.
+func Batch[T any](ctx context.Context, in <-chan T, size int, maxLatency time.Duration, opts ...FanOption) <-chan []T {
+	cfg := newFanConfig(opts)
+	out := make(chan []T, cfg.buffer)
+
+	go func() {
+		defer close(out)
+
+		if !runBatch(ctx, in, out, size, maxLatency) && cfg.drain {
+			drain(in)
+		}
+	}()
+
+	return out
+}
+
+// TumblingWindow groups the values of `in` in windows of `d`: consecutive,
+// non-overlapping periods of time. The windows without values are skipped.
+func TumblingWindow[T any](ctx context.Context, in <-chan T, d time.Duration, opts ...FanOption) <-chan []T {
+	cfg := newFanConfig(opts)
+	out := make(chan []T, cfg.buffer)
+
+	go func() {
+		defer close(out)
+
+		if !runTumblingWindow(ctx, in, out, d) && cfg.drain {
+			drain(in)
+		}
+	}()
+
+	return out
+}
+
+// SlidingWindow emits, every `every`, the values of `in` received during the
+// last `size`. Windows overlap when `every` is shorter than `size`, so a
+// value can be part of several of them. The windows without values are
+// skipped, and a last window is emitted when `in` is closed if values
+// arrived since the previous one.
+func SlidingWindow[T any](ctx context.Context, in <-chan T, size, every time.Duration, opts ...FanOption) <-chan []T {
+	cfg := newFanConfig(opts)
+	out := make(chan []T, cfg.buffer)
+
+	type timed struct {
+		at    time.Time
+		value T
+	}
+
+	go func() {
+		defer close(out)
+
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+
+		var values []timed
+		unseen := false // Whether values arrived since the last window
+
+		window := func(now time.Time) []T {
+			// Forget the values that slid out of the window.
+			start := now.Add(-size)
+			for len(values) > 0 && !values[0].at.After(start) {
+				values = values[1:]
+			}
+
+			w := make([]T, len(values))
+			for i, v := range values {
+				w[i] = v.value
+			}
+			unseen = false
+
+			return w
+		}
+
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					if unseen { // The last values still deserve a window
+						send(ctx, out, window(time.Now()))
+					}
+					return
+				}
+
+				values = append(values, timed{time.Now(), v})
+				unseen = true
+			case now := <-ticker.C:
+				w := window(now)
+				if len(w) == 0 {
+					continue
+				}
+
+				if !send(ctx, out, w) {
+					abandon(cfg, []<-chan T{in})
+					return
+				}
+			case <-ctx.Done():
+				abandon(cfg, []<-chan T{in})
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// KeyedBatch is a batch of values sharing the same key.
+type KeyedBatch[T any] struct {
+	Key    string
+	Values []T
+}
+
+// BatchByKey is Batch keeping a separate batch for every key, so each batch
+// only holds values with the same key.
+func BatchByKey[T any](ctx context.Context, in <-chan T, key func(T) string, size int, maxLatency time.Duration, opts ...FanOption) <-chan KeyedBatch[T] {
+	cfg := newFanConfig(opts)
+	out := make(chan KeyedBatch[T], cfg.buffer)
+
+	type openBatch struct {
+		values   []T
+		deadline time.Time
+	}
+
+	go func() {
+		defer close(out)
+
+		batches := make(map[string]*openBatch)
+		order := make([]string, 0) // Keys by age of their batch, oldest first
+
+		// A single timer fires for the batch expiring the soonest.
+		timer := time.NewTimer(maxLatency)
+		defer timer.Stop()
+
+		rearm := func() {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+
+			if len(order) > 0 {
+				timer.Reset(time.Until(batches[order[0]].deadline))
+			}
+		}
+		rearm()
+
+		emit := func(k string) bool {
+			b := batches[k]
+			delete(batches, k)
+			for i, o := range order {
+				if o == k {
+					order = append(order[:i], order[i+1:]...)
+					break
+				}
+			}
+
+			return send(ctx, out, KeyedBatch[T]{Key: k, Values: b.values})
+		}
+
+		for {
+			select {
+			case v, ok := <-in:
+				if !ok {
+					for len(order) > 0 {
+						if !emit(order[0]) {
+							return
+						}
+					}
+					return
+				}
+
+				k := key(v)
+				b, ok := batches[k]
+				if !ok {
+					b = &openBatch{deadline: time.Now().Add(maxLatency)}
+					batches[k] = b
+					order = append(order, k)
+					if len(order) == 1 {
+						rearm()
+					}
+				}
+				b.values = append(b.values, v)
+
+				if len(b.values) >= size {
+					wasOldest := order[0] == k
+					if !emit(k) {
+						abandon(cfg, []<-chan T{in})
+						return
+					}
+					if wasOldest {
+						rearm()
+					}
+				}
+			case now := <-timer.C:
+				for len(order) > 0 && !batches[order[0]].deadline.After(now) {
+					if !emit(order[0]) {
+						abandon(cfg, []<-chan T{in})
+						return
+					}
+				}
+
+				if len(order) > 0 {
+					timer.Reset(time.Until(batches[order[0]].deadline))
+				}
+			case <-ctx.Done():
+				abandon(cfg, []<-chan T{in})
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// runBatch is the loop of Batch. It returns false if it was stopped by ctx.
+func runBatch[T any](ctx context.Context, in <-chan T, out chan<- []T, size int, maxLatency time.Duration) bool {
+	var batch []T
+	timer := time.NewTimer(maxLatency)
+	timer.Stop()
+	defer timer.Stop()
+
+	var expired <-chan time.Time // Only set while a batch is open
+
+	flush := func() bool {
+		expired = nil
+		if !timer.Stop() {
+			select { // Discard a tick left behind, for the next Reset
+			case <-timer.C:
+			default:
+			}
+		}
+
+		if len(batch) == 0 {
+			return true
+		}
+
+		full := batch
+		batch = nil
+
+		return send(ctx, out, full)
+	}
+
+	for {
+		select {
+		case v, ok := <-in:
+			if !ok {
+				return flush()
+			}
+
+			batch = append(batch, v)
+			if len(batch) == 1 {
+				timer.Reset(maxLatency)
+				expired = timer.C
+			}
+
+			if len(batch) >= size && !flush() {
+				return false
+			}
+		case <-expired:
+			if !flush() {
+				return false
+			}
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// runTumblingWindow is the loop of TumblingWindow. It returns false if it was
+// stopped by ctx.
+func runTumblingWindow[T any](ctx context.Context, in <-chan T, out chan<- []T, d time.Duration) bool {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+
+	var window []T
+	for {
+		select {
+		case v, ok := <-in:
+			if !ok {
+				return len(window) == 0 || send(ctx, out, window)
+			}
+
+			window = append(window, v)
+		case <-ticker.C:
+			if len(window) == 0 {
+				continue
+			}
+
+			if !send(ctx, out, window) {
+				return false
+			}
+			window = nil
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
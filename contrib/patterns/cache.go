@@ -0,0 +1,232 @@
+package patterns
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+func CacheDemo() {
+	fmt.Println("Cache Pattern Demo...")
+	ctx := WithKey(context.Background(), "EUR/USD")
+
+	var healthy = true
+	quote := func(ctx context.Context) (string, error) {
+		if !healthy {
+			return "", errors.New("upstream unavailable")
+		}
+
+		return fmt.Sprintf("%s %.4f", KeyFromContext(ctx), 1+rand.Float64()/10), nil
+	}
+
+	quoteWithCache := Cache(Circuit(quote), KeyFromContext,
+		WithTTL(200*time.Millisecond),
+		WithMaxEntries(100),
+		WithStaleIfError(time.Minute),
+	)
+
+	for i := 0; i < 8; i++ {
+		if i == 4 {
+			fmt.Println("upstream goes down...")
+			healthy = false
+		}
+
+		res, err := quoteWithCache(ctx)
+		if err != nil {
+			log.Printf("[ERROR] %v", err)
+		}
+
+		fmt.Println(res)
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// CacheOption customizes the behaviour of Cache.
+type CacheOption func(*cacheConfig)
+
+type cacheConfig struct {
+	ttl                  time.Duration
+	maxEntries           int
+	negativeTTL          time.Duration
+	staleWhileRevalidate time.Duration
+	staleIfError         time.Duration
+	clock                Clock
+}
+
+// WithTTL sets for how long a result is fresh. Defaults to a minute.
+func WithTTL(ttl time.Duration) CacheOption {
+	return func(cfg *cacheConfig) {
+		cfg.ttl = ttl
+	}
+}
+
+// WithMaxEntries bounds the number of keys cached, evicting the least
+// recently used one to make room for a new one. Unbounded by default.
+func WithMaxEntries(n int) CacheOption {
+	return func(cfg *cacheConfig) {
+		cfg.maxEntries = n
+	}
+}
+
+// WithNegativeTTL caches errors for `ttl`, sparing a failing upstream from
+// being hammered. Errors aren't cached by default.
+func WithNegativeTTL(ttl time.Duration) CacheOption {
+	return func(cfg *cacheConfig) {
+		cfg.negativeTTL = ttl
+	}
+}
+
+// WithStaleWhileRevalidate keeps serving an expired result for up to
+// `window` past its expiration while it's refreshed in the background.
+func WithStaleWhileRevalidate(window time.Duration) CacheOption {
+	return func(cfg *cacheConfig) {
+		cfg.staleWhileRevalidate = window
+	}
+}
+
+// WithStaleIfError serves an expired result for up to `window` past its
+// expiration when refreshing it fails, so the callers keep getting the last
+// good value while the upstream is broken.
+func WithStaleIfError(window time.Duration) CacheOption {
+	return func(cfg *cacheConfig) {
+		cfg.staleIfError = window
+	}
+}
+
+// WithCacheClock sets the Clock the cache tells the time with, e.g. a
+// FakeClock in tests.
+func WithCacheClock(c Clock) CacheOption {
+	return func(cfg *cacheConfig) {
+		cfg.clock = c
+	}
+}
+
+// Cache wraps a Circuit or an Effector, caching its results by the key of the
+// calls. Concurrent calls missing the cache for the same key are coalesced,
+// so the upstream is called once per key however many callers are waiting.
+func Cache[F ~func(context.Context) (string, error)](f F, key KeyFunc, opts ...CacheOption) F {
+	cfg := cacheConfig{ttl: time.Minute, clock: realClock{}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	c := &resultCache{
+		cfg:     cfg,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+
+	loader := NewCoalescer(func(ctx context.Context) (string, error) {
+		res, err := f(ctx)
+
+		// An error caused by the context of the caller leading the load, e.g.
+		// one that gave up waiting, says nothing about the upstream, so it's
+		// not cached for the other callers.
+		if err == nil || ctx.Err() == nil {
+			c.store(key(ctx), res, err)
+		}
+
+		return res, err
+	}, key)
+
+	return func(ctx context.Context) (string, error) {
+		k := key(ctx)
+		now := cfg.clock.Now()
+
+		if e, ok := c.lookup(k); ok {
+			if now.Before(e.expiresAt) {
+				return e.value, e.err
+			}
+
+			if e.err == nil && now.Before(e.expiresAt.Add(cfg.staleWhileRevalidate)) {
+				go loader.Do(context.WithoutCancel(ctx))
+				return e.value, nil
+			}
+		}
+
+		res, err := loader.Do(ctx)
+		if err != nil {
+			if e, ok := c.lookup(k); ok && c.servesStaleOnError(e, now) {
+				return e.value, nil
+			}
+		}
+
+		return res, err
+	}
+}
+
+// resultCache holds the cached results in a map for lookups and in a list,
+// ordered from most to least recently used, for the evictions.
+type resultCache struct {
+	cfg cacheConfig
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type cacheEntry struct {
+	key       string
+	value     string
+	err       error
+	expiresAt time.Time
+}
+
+func (c *resultCache) lookup(key string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+
+	c.lru.MoveToFront(elem)
+
+	return *elem.Value.(*cacheEntry), true
+}
+
+func (c *resultCache) store(key string, value string, err error) {
+	now := c.cfg.clock.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ttl := c.cfg.ttl
+	if err != nil {
+		// A good value still servable on errors is worth more than the error.
+		if elem, ok := c.entries[key]; ok && c.servesStaleOnError(*elem.Value.(*cacheEntry), now) {
+			return
+		}
+
+		if c.cfg.negativeTTL <= 0 {
+			return
+		}
+		ttl = c.cfg.negativeTTL
+	}
+
+	entry := &cacheEntry{key: key, value: value, err: err, expiresAt: now.Add(ttl)}
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(entry)
+
+	if c.cfg.maxEntries > 0 && c.lru.Len() > c.cfg.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *resultCache) servesStaleOnError(e cacheEntry, now time.Time) bool {
+	return e.err == nil && now.Before(e.expiresAt.Add(c.cfg.staleIfError))
+}
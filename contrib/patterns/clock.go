@@ -0,0 +1,112 @@
+package patterns
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock abstracts the passing of time away from the patterns depending on it,
+// so they can be driven deterministically by a FakeClock in tests.
+type Clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) ClockTimer
+}
+
+// ClockTimer is a timer created by a Clock. As with `time.Timer`, Stop
+// returns false when the timer already fired or was stopped.
+type ClockTimer interface {
+	Stop() bool
+}
+
+// realClock is the Clock backed by the `time` package.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) AfterFunc(d time.Duration, f func()) ClockTimer {
+	return time.AfterFunc(d, f)
+}
+
+// FakeClock is a Clock whose time only moves when Advance is called.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock   *FakeClock
+	at      time.Time
+	f       func()
+	expired bool // Fired or stopped
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) ClockTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+
+	return t
+}
+
+// Advance moves the clock forward by d, firing in order every timer due in
+// the meantime. Unlike `time.AfterFunc`, the timers' functions are run on the
+// calling goroutine, so they have completed by the time Advance returns.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+
+		var next *fakeTimer
+		pending := c.timers[:0]
+		for _, t := range c.timers {
+			if t.expired {
+				continue
+			}
+			pending = append(pending, t)
+
+			if !t.at.After(target) && (next == nil || t.at.Before(next.at)) {
+				next = t
+			}
+		}
+		c.timers = pending
+
+		if next == nil {
+			c.now = target
+			c.mu.Unlock()
+			return
+		}
+
+		c.now = laterOf(c.now, next.at)
+		next.expired = true
+		c.mu.Unlock()
+
+		next.f()
+	}
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	stopped := !t.expired
+	t.expired = true
+
+	return stopped
+}
@@ -13,11 +13,28 @@ func main() {
 	debounceLastFlag := flag.Bool("debounce-last", false, "Execute Debounce Last Demo")
 	retryFlag := flag.Bool("retry", false, "Execute Retry Demo")
 	throttleFlag := flag.Bool("throttle", false, "Execute Throttle Demo")
+	distributedThrottleFlag := flag.Bool("distributed-throttle", false, "Execute Distributed Throttle Demo")
 	timeoutFlag := flag.Bool("timeout", false, "Execute Time Demo")
 	faninFlag := flag.Bool("fanin", false, "Execute Fan-in Demo")
 	fanoutFlag := flag.Bool("fanout", false, "Execute Fan-out Demo")
 	futureFlag := flag.Bool("future", false, "Execute Future Demo")
 	shardingFlag := flag.Bool("sharding", false, "Execute Sharding Demo")
+	keyedDebounceFlag := flag.Bool("keyed-debounce", false, "Execute Keyed Debounce Demo")
+	throttleMiddlewareFlag := flag.Bool("throttle-middleware", false, "Execute Throttle Middleware Demo")
+	coalesceFlag := flag.Bool("coalesce", false, "Execute Coalesce Demo")
+	debounceBatchFlag := flag.Bool("debounce-batch", false, "Execute Debounce Batch Demo")
+	timeoutFuncFlag := flag.Bool("timeout-func", false, "Execute Timeout Func Demo")
+	cacheFlag := flag.Bool("cache", false, "Execute Cache Demo")
+	adaptiveTimeoutFlag := flag.Bool("adaptive-timeout", false, "Execute Adaptive Timeout Demo")
+	attemptTimeoutFlag := flag.Bool("attempt-timeout", false, "Execute Attempt Timeout Demo")
+	orderedFaninFlag := flag.Bool("ordered-fanin", false, "Execute Ordered Fan-in Demo")
+	deadlinePropagationFlag := flag.Bool("deadline-propagation", false, "Execute Deadline Propagation Demo")
+	broadcastFlag := flag.Bool("broadcast", false, "Execute Broadcast Demo")
+	taggedFaninFlag := flag.Bool("tagged-fanin", false, "Execute Tagged Fan-in Demo")
+	pipelineFlag := flag.Bool("pipeline", false, "Execute Pipeline Demo")
+	workerPoolFlag := flag.Bool("worker-pool", false, "Execute Worker Pool Demo")
+	orderedFanoutFlag := flag.Bool("ordered-fanout", false, "Execute Ordered Fan-out Demo")
+	batchFlag := flag.Bool("batch", false, "Execute Batch Demo")
 
 	flag.Parse()
 
@@ -36,6 +53,9 @@ func main() {
 	if *throttleFlag {
 		patterns.ThrottleDemo()
 	}
+	if *distributedThrottleFlag {
+		patterns.DistributedThrottleDemo()
+	}
 	if *timeoutFlag {
 		patterns.TimeoutDemo()
 	}
@@ -51,6 +71,54 @@ func main() {
 	if *shardingFlag {
 		patterns.ShardingDemo()
 	}
+	if *throttleMiddlewareFlag {
+		patterns.ThrottleMiddlewareDemo()
+	}
+	if *keyedDebounceFlag {
+		patterns.KeyedDebounceDemo()
+	}
+	if *debounceBatchFlag {
+		patterns.DebounceBatchDemo()
+	}
+	if *coalesceFlag {
+		patterns.CoalesceDemo()
+	}
+	if *cacheFlag {
+		patterns.CacheDemo()
+	}
+	if *timeoutFuncFlag {
+		patterns.TimeoutFuncDemo()
+	}
+	if *attemptTimeoutFlag {
+		patterns.AttemptTimeoutDemo()
+	}
+	if *adaptiveTimeoutFlag {
+		patterns.AdaptiveTimeoutDemo()
+	}
+	if *deadlinePropagationFlag {
+		patterns.DeadlinePropagationDemo()
+	}
+	if *orderedFaninFlag {
+		patterns.OrderedFaninDemo()
+	}
+	if *taggedFaninFlag {
+		patterns.TaggedFaninDemo()
+	}
+	if *broadcastFlag {
+		patterns.BroadcastDemo()
+	}
+	if *workerPoolFlag {
+		patterns.WorkerPoolDemo()
+	}
+	if *pipelineFlag {
+		patterns.PipelineDemo()
+	}
+	if *batchFlag {
+		patterns.BatchDemo()
+	}
+	if *orderedFanoutFlag {
+		patterns.OrderedFanoutDemo()
+	}
 
 	// If no flags are set, execute all demos
 	if !(*circuitBreakerFlag ||
@@ -58,22 +126,56 @@ func main() {
 		*debounceLastFlag ||
 		*retryFlag ||
 		*throttleFlag ||
+		*distributedThrottleFlag ||
 		*timeoutFlag ||
 		*faninFlag ||
 		*fanoutFlag ||
 		*futureFlag ||
-		*shardingFlag) {
+		*shardingFlag ||
+		*throttleMiddlewareFlag ||
+		*keyedDebounceFlag ||
+		*debounceBatchFlag ||
+		*coalesceFlag ||
+		*cacheFlag ||
+		*timeoutFuncFlag ||
+		*attemptTimeoutFlag ||
+		*adaptiveTimeoutFlag ||
+		*deadlinePropagationFlag ||
+		*orderedFaninFlag ||
+		*taggedFaninFlag ||
+		*broadcastFlag ||
+		*workerPoolFlag ||
+		*pipelineFlag ||
+		*batchFlag ||
+		*orderedFanoutFlag) {
 		fmt.Println("Executing all demos...")
 		patterns.CircuitBreakerDemo()
 		patterns.DebounceFirstDemo()
 		patterns.DebounceLastDemo()
 		patterns.RetryDemo()
 		patterns.ThrottleDemo()
+		patterns.DistributedThrottleDemo()
 		patterns.TimeoutDemo()
 		patterns.FaninnDemo()
 		patterns.FanoutDemo()
 		patterns.FutureDemo()
 		patterns.ShardingDemo()
+		patterns.ThrottleMiddlewareDemo()
+		patterns.KeyedDebounceDemo()
+		patterns.DebounceBatchDemo()
+		patterns.CoalesceDemo()
+		patterns.CacheDemo()
+		patterns.TimeoutFuncDemo()
+		patterns.AttemptTimeoutDemo()
+		patterns.AdaptiveTimeoutDemo()
+		patterns.DeadlinePropagationDemo()
+		patterns.OrderedFaninDemo()
+		patterns.TaggedFaninDemo()
+		patterns.BroadcastDemo()
+		patterns.WorkerPoolDemo()
+		patterns.PipelineDemo()
+		patterns.BatchDemo()
+		patterns.OrderedFanoutDemo()
 	}
 
 }
@@ -0,0 +1,142 @@
+package patterns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func CoalesceDemo() {
+	fmt.Println("Coalesce Pattern Demo...")
+	ctx := context.Background()
+
+	var executions atomic.Int32
+	expensiveLookup := func(ctx context.Context) (string, error) {
+		executions.Add(1)
+		time.Sleep(200 * time.Millisecond) // Wait introduced to mimic an expensive upstream
+
+		return fmt.Sprintf("profile of %s", KeyFromContext(ctx)), nil
+	}
+
+	lookupWithCoalesce := Coalesce(expensiveLookup, KeyFromContext)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		user := "user:42"
+		if i == 5 {
+			user = "user:7"
+		}
+
+		wg.Add(1)
+		go func(caller int, user string) {
+			defer wg.Done()
+
+			res, err := lookupWithCoalesce(WithKey(ctx, user))
+			if err != nil {
+				log.Printf("[ERROR] %v", err)
+			}
+
+			fmt.Printf("caller #%d: %s\n", caller, res)
+		}(i, user)
+	}
+
+	wg.Wait()
+
+	fmt.Printf("%d callers, %d executions\n", 6, executions.Load())
+}
+
+// Coalescer lets concurrent duplicate calls share a single execution of
+// `circuit` and its result, the way `singleflight` does. Unlike DebounceFirst,
+// which shares results for a window of time, calls are only coalesced while
+// the execution for their key is in flight.
+type Coalescer struct {
+	circuit  Circuit
+	key      KeyFunc
+	detached bool
+
+	mu    sync.Mutex
+	calls map[string]*sharedCall // In-flight executions by key
+}
+
+// CoalesceOption customizes the behaviour of a Coalescer.
+type CoalesceOption func(*Coalescer)
+
+// WithDetachedCall detaches the shared execution from the cancellation of the
+// caller that started it. A caller giving up only stops it from waiting, the
+// execution carries on for the sake of the other callers.
+func WithDetachedCall() CoalesceOption {
+	return func(c *Coalescer) {
+		c.detached = true
+	}
+}
+
+func NewCoalescer(circuit Circuit, key KeyFunc, opts ...CoalesceOption) *Coalescer {
+	c := &Coalescer{
+		circuit: circuit,
+		key:     key,
+		calls:   make(map[string]*sharedCall),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Coalesce wraps `circuit` so the concurrent calls with the same key share one
+// execution.
+func Coalesce(circuit Circuit, key KeyFunc, opts ...CoalesceOption) Circuit {
+	return NewCoalescer(circuit, key, opts...).Do
+}
+
+// Do executes `circuit`, unless an execution for the key of ctx is already in
+// flight, in which case it waits for it and returns its result.
+func (c *Coalescer) Do(ctx context.Context) (string, error) {
+	k := c.key(ctx)
+
+	c.mu.Lock()
+	if call, ok := c.calls[k]; ok {
+		c.mu.Unlock()
+		return call.wait(ctx)
+	}
+
+	call := newSharedCall()
+	c.calls[k] = call
+	c.mu.Unlock()
+
+	if !c.detached {
+		c.execute(ctx, k, call)
+		return call.result, call.err
+	}
+
+	go c.execute(context.WithoutCancel(ctx), k, call)
+
+	return call.wait(ctx)
+}
+
+// Forget makes the next call for `key` start a new execution, rather than
+// joining the one currently in flight, e.g. after learning its result will be
+// outdated.
+func (c *Coalescer) Forget(key string) {
+	c.mu.Lock()
+	delete(c.calls, key)
+	c.mu.Unlock()
+}
+
+func (c *Coalescer) execute(ctx context.Context, key string, call *sharedCall) {
+	defer func() {
+		c.mu.Lock()
+		if c.calls[key] == call { // It may have been forgotten and replaced
+			delete(c.calls, key)
+		}
+		c.mu.Unlock()
+
+		close(call.done)
+	}()
+
+	call.result, call.err = c.circuit(ctx)
+}
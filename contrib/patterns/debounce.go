@@ -0,0 +1,124 @@
+package patterns
+
+import (
+	"context"
+	"time"
+)
+
+// DebounceOption customizes the behaviour of the debounce functions.
+type DebounceOption func(*debounceConfig)
+
+type debounceConfig struct {
+	window   DebounceWindow
+	detached bool
+	idle     time.Duration
+	maxWait  time.Duration
+	maxBatch int
+	clock    Clock
+}
+
+func newDebounceConfig(opts []DebounceOption) debounceConfig {
+	cfg := debounceConfig{clock: realClock{}}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return cfg
+}
+
+// DebounceWindow tells DebounceFirst where the window during which the
+// cached result is returned is measured from.
+type DebounceWindow int
+
+const (
+	// WindowFromCompletion measures the window from the moment the call to
+	// `circuit` returns, so a slow upstream doesn't eat into it. It's the
+	// default.
+	WindowFromCompletion DebounceWindow = iota
+
+	// WindowFromLeadingEdge measures the window from the moment the call to
+	// `circuit` starts, i.e. the first call of the cluster.
+	WindowFromLeadingEdge
+)
+
+// WithDebounceWindow sets where the debounce window is measured from.
+func WithDebounceWindow(w DebounceWindow) DebounceOption {
+	return func(cfg *debounceConfig) {
+		cfg.window = w
+	}
+}
+
+// WithDetachedContext makes DebounceLast run `circuit` with a context that is
+// never canceled, so the trailing call completes even if the caller the
+// context belongs to gave up waiting. The values of the context are kept.
+func WithDetachedContext() DebounceOption {
+	return func(cfg *debounceConfig) {
+		cfg.detached = true
+	}
+}
+
+// WithIdleEviction sets for how long the keyed debouncers keep the state of a
+// key that isn't being called. It should be longer than `d`, or a key could be
+// evicted while its call is still pending. Defaults to a minute.
+func WithIdleEviction(idle time.Duration) DebounceOption {
+	return func(cfg *debounceConfig) {
+		cfg.idle = idle
+	}
+}
+
+// WithMaxWait bounds how long DebounceLast and DebounceBatch can postpone
+// the trailing call: it is forced at most `maxWait` after the first call of
+// the cluster, even if the calls keep coming less than `d` apart.
+func WithMaxWait(maxWait time.Duration) DebounceOption {
+	return func(cfg *debounceConfig) {
+		cfg.maxWait = maxWait
+	}
+}
+
+// WithMaxBatchSize makes DebounceBatch hand the batch over as soon as it
+// collects `n` calls, without waiting for the window to end.
+func WithMaxBatchSize(n int) DebounceOption {
+	return func(cfg *debounceConfig) {
+		cfg.maxBatch = n
+	}
+}
+
+// WithClock sets the Clock the debouncers tell the time with, e.g. a
+// FakeClock in tests.
+func WithClock(c Clock) DebounceOption {
+	return func(cfg *debounceConfig) {
+		cfg.clock = c
+	}
+}
+
+// sharedCall is a call to `circuit` whose result is shared by every caller
+// of the same cluster, or of the same key when coalescing. `done` is closed
+// once `result` and `err` are set.
+type sharedCall struct {
+	done   chan struct{}
+	result string
+	err    error
+}
+
+func newSharedCall() *sharedCall {
+	return &sharedCall{done: make(chan struct{})}
+}
+
+// wait blocks until the call completes or the caller's ctx is done, whatever
+// happens first.
+func (c *sharedCall) wait(ctx context.Context) (string, error) {
+	select {
+	case <-c.done:
+		return c.result, c.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func laterOf(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,153 @@
+package patterns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+func DebounceBatchDemo() {
+	fmt.Println("Debounce Batch Pattern Demo...")
+	ctx := context.Background()
+
+	reindexWithDebounceBatch := DebounceBatch(reindexDocuments, 100*time.Millisecond, WithMaxBatchSize(4))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(doc string) {
+			defer wg.Done()
+
+			res, err := reindexWithDebounceBatch(ctx, doc)
+			if err != nil {
+				log.Printf("[ERROR] %v", err)
+			}
+
+			fmt.Println(res)
+		}(fmt.Sprintf("doc-%d", i))
+
+		time.Sleep(30 * time.Millisecond)
+	}
+
+	wg.Wait()
+}
+
+// BatchHandler handles at once the arguments of all the calls of a batch. It
+// must return one result per argument, in the same order as `args`.
+type BatchHandler[A, R any] func(ctx context.Context, args []A) ([]R, error)
+
+// DebounceBatch is a coalescing relative of DebounceLast: rather than dropping
+// the calls of a cluster in favour of the trailing one, it collects the
+// argument of every call and hands them all to `handler` in a single batch
+// once the calls stop for `d` (or the max wait or max batch size are hit).
+// Each caller blocks until the batch is handled and gets its own result.
+func DebounceBatch[A, R any](handler BatchHandler[A, R], d time.Duration, opts ...DebounceOption) func(context.Context, A) (R, error) {
+	cfg := newDebounceConfig(opts)
+
+	var m sync.Mutex
+	var current *debounceBatch[A, R] // Batch collecting the calls
+
+	// handle runs the batch with the context of its latest caller.
+	handle := func(b *debounceBatch[A, R]) {
+		ctx := b.ctx
+		if cfg.detached {
+			ctx = context.WithoutCancel(ctx)
+		}
+
+		defer close(b.done)
+
+		b.results, b.err = handler(ctx, b.args)
+		if b.err == nil && len(b.results) != len(b.args) {
+			b.err = fmt.Errorf("batch handler returned %d results for %d arguments", len(b.results), len(b.args))
+		}
+	}
+
+	// fire hands the batch over when its timer fires, unless it was already
+	// handed over for being full.
+	fire := func(b *debounceBatch[A, R]) func() {
+		return func() {
+			m.Lock()
+			if current != b {
+				m.Unlock()
+				return
+			}
+			current = nil
+			m.Unlock()
+
+			handle(b)
+		}
+	}
+
+	delay := func(b *debounceBatch[A, R], now time.Time) time.Duration {
+		if cfg.maxWait <= 0 {
+			return d
+		}
+
+		return max(0, min(d, b.start.Add(cfg.maxWait).Sub(now)))
+	}
+
+	return func(ctx context.Context, arg A) (R, error) {
+		m.Lock()
+
+		now := cfg.clock.Now()
+
+		b := current
+		switch {
+		case b == nil: // First call of a new batch
+			b = &debounceBatch[A, R]{done: make(chan struct{}), start: now}
+			b.timer = cfg.clock.AfterFunc(delay(b, now), fire(b))
+			current = b
+		case b.timer.Stop(): // Postpone the batch, unless it's about to be handed over
+			b.timer = cfg.clock.AfterFunc(delay(b, now), fire(b))
+		}
+
+		i := len(b.args)
+		b.args = append(b.args, arg)
+		b.ctx = ctx
+
+		if cfg.maxBatch > 0 && len(b.args) >= cfg.maxBatch { // Full, hand it over right away
+			b.timer.Stop()
+			current = nil
+			go handle(b)
+		}
+
+		m.Unlock()
+
+		select {
+		case <-b.done:
+			var zero R
+			if b.err != nil {
+				return zero, b.err
+			}
+			return b.results[i], nil
+		case <-ctx.Done():
+			var zero R
+			return zero, ctx.Err()
+		}
+	}
+}
+
+type debounceBatch[A, R any] struct {
+	ctx   context.Context // Context of the latest call
+	args  []A
+	start time.Time
+	timer ClockTimer
+
+	done    chan struct{}
+	results []R
+	err     error
+}
+
+func reindexDocuments(ctx context.Context, docs []string) ([]string, error) {
+	batch := strings.Join(docs, ", ")
+
+	results := make([]string, len(docs))
+	for i, doc := range docs {
+		results[i] = fmt.Sprintf("%s reindexed in batch [%s]", doc, batch)
+	}
+
+	return results, nil
+}
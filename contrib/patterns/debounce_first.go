@@ -26,6 +26,27 @@ func DebounceFirstDemo() {
 		randLatency := time.Duration(randNum) * time.Millisecond
 		time.Sleep(randLatency) // Wait introduced to mimic a random service latency
 	}
+
+	// Concurrent callers overlapping a slow call share its result instead of
+	// queueing behind it.
+	slowFeatureWithDebounceFirst := DebounceFirst(slowStatefulFeature(), 100*time.Millisecond, WithDebounceWindow(WindowFromLeadingEdge))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(caller int) {
+			defer wg.Done()
+
+			res, err := slowFeatureWithDebounceFirst(ctx)
+			if err != nil {
+				log.Printf("[ERROR] %v", err)
+			}
+
+			fmt.Printf("caller #%d: %s\n", caller, res)
+		}(i)
+	}
+
+	wg.Wait()
 }
 
 // We start by defining a function type witht he signature of the function we
@@ -37,14 +58,18 @@ func DebounceFirstDemo() {
 // forward compared to function-last because it only needs to track the last
 // time it was called and return a cached result if it's called again less than
 // `d` duration after.
-func DebounceFirst(circuit Circuit, d time.Duration) Circuit {
-	// This of `DebounceFirst` takes pains to ensure thread safety by wrapping the
-	// entire function in a mutex. While this will force overlapping calls at the
-	// start of a cluster to have to wait until the result is cahed, it also
-	// guarantees that `circuit` is called exactly once, at the very beginning of
-	// a cluster.
+func DebounceFirst(circuit Circuit, d time.Duration, opts ...DebounceOption) Circuit {
+	// This of `DebounceFirst` takes pains to ensure thread safety by guarding
+	// its state with a mutex, but unlike the book's version the mutex isn't
+	// held while `circuit` runs, otherwise a slow upstream would block every
+	// caller. Instead, callers overlapping an in-flight call join it and share
+	// its result (like `singleflight` does), which still guarantees that
+	// `circuit` is called exactly once, at the very beginning of a cluster.
+	cfg := newDebounceConfig(opts)
+
 	var m sync.Mutex
 	var threshold time.Time
+	var inflight *sharedCall
 
 	var result string
 	var err error
@@ -52,18 +77,51 @@ func DebounceFirst(circuit Circuit, d time.Duration) Circuit {
 	return func(ctx context.Context) (string, error) {
 		m.Lock()
 
-		defer func() {
-			threshold = time.Now().Add(d)
+		now := cfg.clock.Now()
+		extended := laterOf(threshold, now.Add(d)) // Every call extends its cluster
+
+		if call := inflight; call != nil {
+			threshold = extended
 			m.Unlock()
-		}()
+			return call.wait(ctx)
+		}
 
-		if time.Now().Before(threshold) {
-			return result, err
+		if now.Before(threshold) {
+			threshold = extended
+			res, e := result, err
+			m.Unlock()
+			return res, e
 		}
 
-		result, err = circuit(ctx)
+		// First call of a new cluster, it's the one calling `circuit`.
+		call := newSharedCall()
+		inflight = call
+		threshold = extended
+		m.Unlock()
 
-		return result, err
+		defer func() {
+			m.Lock()
+			inflight = nil
+
+			// An error caused by the context of this caller, e.g. because it
+			// gave up waiting, says nothing about the upstream. It isn't
+			// served to the calls to come, the next one calls `circuit` again.
+			if call.err != nil && ctx.Err() != nil {
+				threshold = time.Time{}
+			} else {
+				result, err = call.result, call.err
+				if cfg.window == WindowFromCompletion {
+					threshold = laterOf(threshold, cfg.clock.Now().Add(d))
+				}
+			}
+			m.Unlock()
+
+			close(call.done) // Release the callers that joined the call
+		}()
+
+		call.result, call.err = circuit(ctx)
+
+		return call.result, call.err
 	}
 }
 
@@ -78,3 +136,13 @@ func statefulFeature() Circuit {
 		return fmt.Sprintf("count = %d", count), nil
 	}
 }
+
+func slowStatefulFeature() Circuit {
+	feature := statefulFeature()
+
+	return func(ctx context.Context) (string, error) {
+		time.Sleep(200 * time.Millisecond) // Wait introduced to mimic a slow upstream
+
+		return feature(ctx)
+	}
+}
@@ -0,0 +1,132 @@
+package patterns
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+func KeyedDebounceDemo() {
+	fmt.Println("Keyed Debounce Pattern Demo...")
+	ctx := context.Background()
+
+	saveUserWithKeyedDebounce := KeyedDebounceLast(saveUser, 100*time.Millisecond, KeyFromContext)
+
+	// A burst of updates for alice doesn't suppress the ones for bob, each of
+	// them gets its own trailing call.
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		user := "alice"
+		if i%3 == 0 {
+			user = "bob"
+		}
+
+		wg.Add(1)
+		go func(caller int, user string) {
+			defer wg.Done()
+
+			res, err := saveUserWithKeyedDebounce(WithKey(ctx, user))
+			if err != nil {
+				log.Printf("[ERROR] %v", err)
+			}
+
+			fmt.Printf("caller #%d: %s\n", caller, res)
+		}(i, user)
+
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	wg.Wait()
+}
+
+// KeyFunc derives from the context of a call the key, e.g. the entity, the
+// call is about.
+type KeyFunc func(ctx context.Context) string
+
+type callKey struct{}
+
+// WithKey returns a copy of ctx carrying the key of the call made with it.
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, callKey{}, key)
+}
+
+// KeyFromContext is the KeyFunc returning the key set with WithKey, or the
+// empty key if none was set.
+func KeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(callKey{}).(string)
+
+	return key
+}
+
+// KeyedDebounceFirst is DebounceFirst with a separate window per key, so the
+// calls about one key don't suppress the calls about another.
+func KeyedDebounceFirst(circuit Circuit, d time.Duration, key KeyFunc, opts ...DebounceOption) Circuit {
+	return keyedDebounce(circuit, d, key, DebounceFirst, opts)
+}
+
+// KeyedDebounceLast is DebounceLast with a separate window per key, so the
+// calls about one key don't postpone the calls about another.
+func KeyedDebounceLast(circuit Circuit, d time.Duration, key KeyFunc, opts ...DebounceOption) Circuit {
+	return keyedDebounce(circuit, d, key, DebounceLast, opts)
+}
+
+type keyedDebouncer struct {
+	debounced Circuit
+	lastUsed  time.Time
+}
+
+// keyedDebounce lazily creates a debouncer for every key it sees. To avoid
+// leaking memory in long-running services, the debouncers of keys that
+// haven't been called for the idle period are evicted, on the first call made
+// after the idle period elapsed since the previous eviction.
+func keyedDebounce(
+	circuit Circuit,
+	d time.Duration,
+	key KeyFunc,
+	debounce func(Circuit, time.Duration, ...DebounceOption) Circuit,
+	opts []DebounceOption,
+) Circuit {
+	cfg := newDebounceConfig(opts)
+
+	idle := cfg.idle
+	if idle <= 0 {
+		idle = time.Minute
+	}
+
+	var m sync.Mutex
+	debouncers := make(map[string]*keyedDebouncer)
+	lastEviction := cfg.clock.Now()
+
+	return func(ctx context.Context) (string, error) {
+		k := key(ctx)
+		now := cfg.clock.Now()
+
+		m.Lock()
+
+		if now.Sub(lastEviction) >= idle {
+			for evictable, debouncer := range debouncers {
+				if now.Sub(debouncer.lastUsed) >= idle {
+					delete(debouncers, evictable)
+				}
+			}
+			lastEviction = now
+		}
+
+		debouncer, ok := debouncers[k]
+		if !ok {
+			debouncer = &keyedDebouncer{debounced: debounce(circuit, d, opts...)}
+			debouncers[k] = debouncer
+		}
+		debouncer.lastUsed = now
+
+		m.Unlock()
+
+		return debouncer.debounced(ctx)
+	}
+}
+
+func saveUser(ctx context.Context) (string, error) {
+	return fmt.Sprintf("saved %s", KeyFromContext(ctx)), nil
+}
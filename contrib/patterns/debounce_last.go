@@ -14,78 +14,117 @@ func DebounceLastDemo() {
 	ctx := context.Background()
 
 	statefulFeatureWithDebounceLast := DebounceLast(statefulFeature(), 100*time.Millisecond)
+
+	// Callers of the same cluster all wait for, and share, the trailing call.
+	var wg sync.WaitGroup
 	for i := 0; i < 10; i++ {
-		res, err := statefulFeatureWithDebounceLast(ctx)
-		if err != nil {
-			log.Printf("[ERROR] %v", err)
-		}
+		wg.Add(1)
+		go func(caller int) {
+			defer wg.Done()
 
-		fmt.Println(res)
+			res, err := statefulFeatureWithDebounceLast(ctx)
+			if err != nil {
+				log.Printf("[ERROR] %v", err)
+			}
+
+			fmt.Printf("caller #%d: %s\n", caller, res)
+		}(i)
 
 		var randNum float32 = 150 * rand.Float32()
 		randLatency := time.Duration(randNum) * time.Millisecond
 		time.Sleep(randLatency) // Wait introduced to mimic a random service latency
 	}
+
+	wg.Wait()
+
+	// Without a max wait, calls spaced below `d` would postpone the trailing
+	// call forever. With it, the call is forced every 250ms.
+	statefulFeatureWithMaxWait := DebounceLast(statefulFeature(), 100*time.Millisecond, WithMaxWait(250*time.Millisecond))
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(caller int) {
+			defer wg.Done()
+
+			res, err := statefulFeatureWithMaxWait(ctx)
+			if err != nil {
+				log.Printf("[ERROR] %v", err)
+			}
+
+			fmt.Printf("caller #%d: %s\n", caller, res)
+		}(i)
+
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	wg.Wait()
 }
 
-// DebounceLast implementation involves the use of a `time.Ticker` to determine
-// whether enough time has passed since the function was last called, and to
-// call `circuit` when it has. Alternatively.
-func DebounceLast(circuit Circuit, d time.Duration) Circuit {
-	// This of `DebounceLast` takes pains to ensure thread safety by wrapping the
-	// entire function in a mutex. While this will force overlapping calls at the
-	// start of a cluster to have to wait until the result is cahed, it also
-	// guarantees that `circuit` is called exactly once, at the very beginning of
-	// a cluster.
+// DebounceLast implementation involves the use of a `time.Timer` that is reset
+// every time the function is called, and calls `circuit` when it finally
+// fires, `d` after the last call of the cluster. Every caller of the cluster
+// blocks until that trailing call completes and gets its result, so nobody is
+// handed the stale result of a previous cluster.
+func DebounceLast(circuit Circuit, d time.Duration, opts ...DebounceOption) Circuit {
+	cfg := newDebounceConfig(opts)
+
 	var m sync.Mutex
-	var once sync.Once
-	var threshold time.Time
-	var ticker *time.Ticker
+	var running sync.Mutex // Serializes the trailing calls of successive clusters
+	var timer ClockTimer
+	var pending *sharedCall // Call the current cluster is waiting for
+	var clusterStart time.Time
+	var latest context.Context
+
+	// fire runs the trailing call with the context of the latest caller, which
+	// is the one most likely to still be waiting for it. The cluster is closed
+	// right away, so new calls start the next one, but its trailing call waits
+	// for this one to complete: `circuit` is never called concurrently.
+	fire := func() {
+		m.Lock()
+		call, ctx := pending, latest
+		pending, latest = nil, nil
+		m.Unlock()
 
-	var result string
-	var err error
+		running.Lock()
+		defer running.Unlock()
+
+		if cfg.detached {
+			ctx = context.WithoutCancel(ctx)
+		}
+
+		defer close(call.done)
+
+		call.result, call.err = circuit(ctx)
+	}
+
+	// delay is how long to wait for the trailing call from `now`, which is
+	// `d` unless that would exceed the max wait of the cluster.
+	delay := func(now time.Time) time.Duration {
+		if cfg.maxWait <= 0 {
+			return d
+		}
+
+		return max(0, min(d, clusterStart.Add(cfg.maxWait).Sub(now)))
+	}
 
 	return func(ctx context.Context) (string, error) {
 		m.Lock()
-		defer m.Unlock()
-
-		threshold = time.Now().Add(d)
-
-		// Almost the entire function is run inside this `Do` method of a
-		// `sync.Once` value, which ensures that (as its name suggests) the
-		// contained function is run exactly once.
-		once.Do(func() {
-			ticker = time.NewTicker(100 * time.Millisecond)
-
-			go func() {
-				defer func() {
-					m.Lock()
-					ticker.Stop()
-					once = sync.Once{}
-					m.Unlock()
-				}()
-
-				for {
-					select {
-					case <-ticker.C:
-						m.Lock()
-						if time.Now().After(threshold) {
-							result, err = circuit(ctx)
-							m.Unlock()
-							return
-						}
-						m.Unlock()
-					case <-ctx.Done():
-						m.Lock()
-						result, err = "", ctx.Err()
-						m.Unlock()
-						return
-					}
-				}
-			}()
-
-		})
-
-		return result, err
+
+		now := cfg.clock.Now()
+		latest = ctx
+
+		switch {
+		case pending == nil: // First call of a new cluster
+			pending = newSharedCall()
+			clusterStart = now
+			timer = cfg.clock.AfterFunc(delay(now), fire)
+		case timer.Stop(): // Postpone the trailing call, unless it already fired
+			timer = cfg.clock.AfterFunc(delay(now), fire)
+		}
+
+		call := pending
+		m.Unlock()
+
+		return call.wait(ctx)
 	}
 }
@@ -0,0 +1,154 @@
+package patterns
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// armingClock is a FakeClock telling when a timer is armed, which DebounceLast
+// does once per call, so the tests know a call was registered before moving
+// the clock.
+type armingClock struct {
+	*FakeClock
+	armed chan struct{}
+}
+
+func (c armingClock) AfterFunc(d time.Duration, f func()) ClockTimer {
+	t := c.FakeClock.AfterFunc(d, f)
+	c.armed <- struct{}{}
+
+	return t
+}
+
+type debounceLastFixture struct {
+	t         *testing.T
+	clock     armingClock
+	calls     atomic.Int32
+	debounced Circuit
+}
+
+func newDebounceLastFixture(t *testing.T, d time.Duration, opts ...DebounceOption) *debounceLastFixture {
+	f := &debounceLastFixture{
+		t:     t,
+		clock: armingClock{NewFakeClock(time.Unix(0, 0)), make(chan struct{})},
+	}
+
+	circuit := func(ctx context.Context) (string, error) {
+		return fmt.Sprint(f.calls.Add(1)), nil
+	}
+	f.debounced = DebounceLast(circuit, d, append(opts, WithClock(f.clock))...)
+
+	return f
+}
+
+// call calls the debounced circuit in the background, returning once the call
+// is registered, and the channel its result is delivered on.
+func (f *debounceLastFixture) call() <-chan string {
+	res := make(chan string, 1)
+	go func() {
+		r, err := f.debounced(context.Background())
+		if err != nil {
+			f.t.Errorf("unexpected error: %v", err)
+		}
+		res <- r
+	}()
+
+	<-f.clock.armed
+
+	return res
+}
+
+func (f *debounceLastFixture) advance(d time.Duration) {
+	f.clock.Advance(d)
+}
+
+func (f *debounceLastFixture) expectCalls(want int32) {
+	f.t.Helper()
+
+	if got := f.calls.Load(); got != want {
+		f.t.Fatalf("circuit called %d times, want %d", got, want)
+	}
+}
+
+func (f *debounceLastFixture) expectResult(res <-chan string, want string) {
+	f.t.Helper()
+
+	select {
+	case got := <-res:
+		if got != want {
+			f.t.Fatalf("got result %q, want %q", got, want)
+		}
+	case <-time.After(time.Second):
+		f.t.Fatal("no result")
+	}
+}
+
+func TestDebounceLastFiresAfterLastCall(t *testing.T) {
+	f := newDebounceLastFixture(t, 100*time.Millisecond)
+
+	first := f.call()
+	f.advance(60 * time.Millisecond)
+	second := f.call() // Postpones the trailing call to 160ms
+
+	f.advance(99 * time.Millisecond)
+	f.expectCalls(0)
+
+	f.advance(time.Millisecond)
+	f.expectCalls(1)
+	f.expectResult(first, "1")
+	f.expectResult(second, "1")
+}
+
+func TestDebounceLastMaxWait(t *testing.T) {
+	f := newDebounceLastFixture(t, 100*time.Millisecond, WithMaxWait(250*time.Millisecond))
+
+	// Calls 50ms apart would postpone the trailing call forever without the
+	// max wait.
+	var results []<-chan string
+	for i := 0; i < 5; i++ {
+		f.expectCalls(0)
+		results = append(results, f.call())
+		f.advance(50 * time.Millisecond)
+	}
+
+	f.expectCalls(1) // Forced at 250ms
+	for _, res := range results {
+		f.expectResult(res, "1")
+	}
+}
+
+func TestDebounceLastSharesResultInCluster(t *testing.T) {
+	f := newDebounceLastFixture(t, 100*time.Millisecond)
+
+	var results []<-chan string
+	for i := 0; i < 5; i++ {
+		results = append(results, f.call())
+		f.advance(10 * time.Millisecond)
+	}
+
+	f.advance(100 * time.Millisecond)
+	f.expectCalls(1)
+	for _, res := range results {
+		f.expectResult(res, "1")
+	}
+}
+
+func TestDebounceLastNextClusterStartsFresh(t *testing.T) {
+	f := newDebounceLastFixture(t, 100*time.Millisecond)
+
+	first := f.call()
+	f.advance(100 * time.Millisecond)
+	f.expectCalls(1)
+	f.expectResult(first, "1")
+
+	second := f.call()
+	f.advance(99 * time.Millisecond)
+	f.expectCalls(1) // The new cluster has its own window
+
+	f.advance(time.Millisecond)
+	f.expectCalls(2)
+	f.expectResult(second, "2")
+}
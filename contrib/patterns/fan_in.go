@@ -1,6 +1,7 @@
 package patterns
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -25,34 +26,138 @@ func FaninnDemo() {
 		}()
 	}
 
-	dest := Funnel(sources...)
+	// The consumer is only interested in what arrives in the first 250ms. As
+	// the producers don't watch the context, the sources are drained so they
+	// don't block forever on their sends.
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+
+	dest := Funnel(ctx, sources, WithBuffer(3), WithDrainOnCancel())
 	for d := range dest {
 		fmt.Println(d)
 	}
 }
 
-// Funnel is implemented as a function that receives zero to N input channels (Sources). For each input channel in Sources, the Funnel function starts a separate goroutine to read values from its assigned channel and forward them to a single output channel shared by all of the goroutines (Destination).
-func Funnel(sources ...<-chan int) <-chan int {
-	dest := make(chan int) // The shared output channel
+// FanOption customizes the behaviour of the fan-in and fan-out functions.
+type FanOption func(*fanConfig)
+
+type fanConfig struct {
+	buffer        int
+	drain         bool
+	cancelOnError bool
+	strategy      splitStrategy
+}
+
+func newFanConfig(opts []FanOption) fanConfig {
+	var cfg fanConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return cfg
+}
+
+// WithBuffer sets the capacity of the output channels. They are unbuffered by
+// default.
+func WithBuffer(n int) FanOption {
+	return func(cfg *fanConfig) {
+		cfg.buffer = n
+	}
+}
+
+// WithDrainOnCancel keeps reading, and discarding, the values of the input
+// channels after the context is canceled, until they are closed. It frees
+// producers that don't watch the context themselves from blocking forever on
+// their sends. By default the input channels are abandoned on cancellation.
+func WithDrainOnCancel() FanOption {
+	return func(cfg *fanConfig) {
+		cfg.drain = true
+	}
+}
+
+// Funnel is implemented as a function that receives zero to N input channels
+// (Sources). For each input channel in Sources, the Funnel function starts a
+// separate goroutine to read values from its assigned channel and forward them
+// to a single output channel shared by all of the goroutines (Destination).
+// Forwarding stops when ctx is canceled, so no goroutine is left blocked on
+// a Destination nobody reads anymore, and Destination is closed.
+func Funnel[T any](ctx context.Context, sources []<-chan T, opts ...FanOption) <-chan T {
+	return funnel(ctx, sources, newFanConfig(opts), spawnGoroutine)
+}
+
+// funnel implements Funnel, starting its goroutines with `spawn` so a
+// Pipeline can keep track of them.
+func funnel[T any](ctx context.Context, sources []<-chan T, cfg fanConfig, spawn func(func())) <-chan T {
+	dest := make(chan T, cfg.buffer) // The shared output channel
 
 	var wg sync.WaitGroup // Used to automatically close dest when all sources are closed
 
 	wg.Add(len(sources)) // Set size of the WaitGroup
 
 	for _, ch := range sources { // Start a goroutine for each source
-		go func(c <-chan int) {
-			defer wg.Done() // Notify WaiGroup when c closes
+		c := ch
+		spawn(func() {
+			completed := forward(ctx, c, dest)
+			wg.Done() // Notify WaiGroup when c closes or ctx is canceled
 
-			for n := range c {
-				dest <- n
+			if !completed && cfg.drain {
+				drain(c)
 			}
-		}(ch)
+		})
 	}
 
-	go func() { // Start a goroutine to close dest after all sources close
+	spawn(func() { // Start a goroutine to close dest after all sources close
 		wg.Wait()
 		close(dest)
-	}()
+	})
 
 	return dest
 }
+
+// forward sends the values of `c` to `dest` until `c` is closed, in which case
+// it returns true, or ctx is canceled.
+func forward[T any](ctx context.Context, c <-chan T, dest chan<- T) bool {
+	for {
+		select {
+		case n, ok := <-c:
+			if !ok {
+				return true
+			}
+
+			select {
+			case dest <- n:
+			case <-ctx.Done():
+				return false
+			}
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// spawnGoroutine starts f on a goroutine of its own, for the functions that
+// can also run their goroutines through a Pipeline.
+func spawnGoroutine(f func()) {
+	go f()
+}
+
+// drain discards the values of `c` until it's closed.
+func drain[T any](c <-chan T) {
+	for range c {
+	}
+}
+
+// abandon stops reading the sources that are still open (non-nil) because
+// the context was canceled, draining them in the background if the drain
+// option is set.
+func abandon[T any](cfg fanConfig, sources []<-chan T) {
+	if !cfg.drain {
+		return
+	}
+
+	for _, c := range sources {
+		if c != nil {
+			go drain(c)
+		}
+	}
+}
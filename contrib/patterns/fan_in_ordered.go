@@ -0,0 +1,228 @@
+package patterns
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+func OrderedFaninDemo() {
+	fmt.Println("Ordered Fan-in Pattern Demo...")
+	ctx := context.Background()
+
+	// Sources already holding all of their values, so several are always ready.
+	ready := func(values ...string) <-chan string {
+		ch := make(chan string, len(values))
+		for _, v := range values {
+			ch <- v
+		}
+		close(ch)
+
+		return ch
+	}
+
+	fmt.Println("priority:")
+	for v := range PriorityFunnel(ctx, []<-chan string{ready("urgent-1", "urgent-2"), ready("normal-1", "normal-2")}) {
+		fmt.Println(v)
+	}
+
+	fmt.Println("round-robin:")
+	for v := range RoundRobinFunnel(ctx, []<-chan string{ready("a-1", "a-2", "a-3"), ready("b-1", "b-2"), ready("c-1")}) {
+		fmt.Println(v)
+	}
+
+	// Time-ordered event feeds merged into a single time-ordered feed.
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	feed := func(offsets ...int) <-chan time.Time {
+		ch := make(chan time.Time, len(offsets))
+		for _, o := range offsets {
+			ch <- base.Add(time.Duration(o) * time.Second)
+		}
+		close(ch)
+
+		return ch
+	}
+
+	fmt.Println("sorted:")
+	sorted := MergeSorted(ctx, []<-chan time.Time{feed(1, 4, 9), feed(2, 3, 10), feed(5)}, time.Time.Before)
+	for t := range sorted {
+		fmt.Println(t.Format(time.TimeOnly))
+	}
+}
+
+// PriorityFunnel is a Funnel that, whenever several sources have a value
+// ready, always forwards the one of the source with the highest priority,
+// sources being listed from highest to lowest priority. Lower priority
+// sources are only read while the higher priority ones have nothing to give.
+func PriorityFunnel[T any](ctx context.Context, sources []<-chan T, opts ...FanOption) <-chan T {
+	return selectFunnel(ctx, sources, func(last int) int { return 0 }, opts)
+}
+
+// RoundRobinFunnel is a Funnel that takes turns between the sources having a
+// value ready, so a busy source can't starve the others.
+func RoundRobinFunnel[T any](ctx context.Context, sources []<-chan T, opts ...FanOption) <-chan T {
+	return selectFunnel(ctx, sources, func(last int) int { return last + 1 }, opts)
+}
+
+// selectFunnel merges the sources from a single goroutine, trying the sources
+// in turn from the one `first` returns, given the last source read from.
+func selectFunnel[T any](ctx context.Context, sources []<-chan T, first func(last int) int, opts []FanOption) <-chan T {
+	cfg := newFanConfig(opts)
+	dest := make(chan T, cfg.buffer)
+
+	go func() {
+		defer close(dest)
+
+		open := append([]<-chan T(nil), sources...) // Closed sources are set to nil
+		remaining := len(open)
+		last := -1
+
+		for remaining > 0 {
+			v, i, ok, canceled := receiveAny(ctx, open, first(last))
+			if canceled {
+				abandon(cfg, open)
+				return
+			}
+
+			if !ok {
+				open[i] = nil
+				remaining--
+				continue
+			}
+			last = i
+
+			select {
+			case dest <- v:
+			case <-ctx.Done():
+				abandon(cfg, open)
+				return
+			}
+		}
+	}()
+
+	return dest
+}
+
+// receiveAny receives a value from any of the non-nil sources. The sources
+// that are ready are tried in order, starting from `start`; when none is, it
+// blocks until one is or ctx is canceled. `ok` is false if the source the
+// value would have come from was closed instead.
+func receiveAny[T any](ctx context.Context, sources []<-chan T, start int) (v T, i int, ok bool, canceled bool) {
+	for n := 0; n < len(sources); n++ {
+		i = (start + n) % len(sources)
+		if sources[i] == nil {
+			continue
+		}
+
+		select {
+		case v, ok = <-sources[i]:
+			return v, i, ok, false
+		default:
+		}
+	}
+
+	// As the number of sources is only known at runtime, the select statement
+	// is built with reflection.
+	cases := make([]reflect.SelectCase, 0, len(sources)+1)
+	indexes := make([]int, 0, len(sources))
+	for i, c := range sources {
+		if c != nil {
+			cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c)})
+			indexes = append(indexes, i)
+		}
+	}
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())})
+
+	chosen, value, ok := reflect.Select(cases)
+	if chosen == len(indexes) {
+		return v, -1, false, true
+	}
+
+	if ok {
+		v, _ = value.Interface().(T) // Comma-ok form, nil interface values assert to false
+	}
+
+	return v, indexes[chosen], ok, false
+}
+
+// MergeSorted merges sources whose values are each sorted according to `less`
+// into a single sorted output (a k-way merge), e.g. to merge time-ordered
+// event feeds. To know which value comes next it needs the next value of
+// every open source, so it waits on a source until it has one or is closed.
+func MergeSorted[T any](ctx context.Context, sources []<-chan T, less func(a, b T) bool, opts ...FanOption) <-chan T {
+	cfg := newFanConfig(opts)
+	dest := make(chan T, cfg.buffer)
+
+	go func() {
+		defer close(dest)
+
+		open := append([]<-chan T(nil), sources...)
+		heads := &mergeHeap[T]{less: less}
+
+		// next pushes the next value of source i onto the heap, reporting
+		// false if ctx was canceled meanwhile.
+		next := func(i int) bool {
+			select {
+			case v, ok := <-open[i]:
+				if !ok {
+					open[i] = nil
+					return true
+				}
+				heap.Push(heads, mergeHead[T]{value: v, source: i})
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for i := range open {
+			if !next(i) {
+				abandon(cfg, open)
+				return
+			}
+		}
+
+		for heads.Len() > 0 {
+			head := heap.Pop(heads).(mergeHead[T])
+
+			select {
+			case dest <- head.value:
+			case <-ctx.Done():
+				abandon(cfg, open)
+				return
+			}
+
+			if !next(head.source) {
+				abandon(cfg, open)
+				return
+			}
+		}
+	}()
+
+	return dest
+}
+
+type mergeHead[T any] struct {
+	value  T
+	source int
+}
+
+// mergeHeap implements `heap.Interface` holding the next value of each source.
+type mergeHeap[T any] struct {
+	heads []mergeHead[T]
+	less  func(a, b T) bool
+}
+
+func (h *mergeHeap[T]) Len() int           { return len(h.heads) }
+func (h *mergeHeap[T]) Less(i, j int) bool { return h.less(h.heads[i].value, h.heads[j].value) }
+func (h *mergeHeap[T]) Swap(i, j int)      { h.heads[i], h.heads[j] = h.heads[j], h.heads[i] }
+func (h *mergeHeap[T]) Push(x any)         { h.heads = append(h.heads, x.(mergeHead[T])) }
+
+func (h *mergeHeap[T]) Pop() any {
+	last := h.heads[len(h.heads)-1]
+	h.heads = h.heads[:len(h.heads)-1]
+
+	return last
+}
@@ -0,0 +1,167 @@
+package patterns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+func TaggedFaninDemo() {
+	fmt.Println("Tagged Fan-in Pattern Demo...")
+	ctx := context.Background()
+
+	counter := func(n int, fail bool) func(context.Context, chan<- int) error {
+		return func(ctx context.Context, out chan<- int) error {
+			for i := 1; i <= n; i++ {
+				select {
+				case out <- i:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				time.Sleep(50 * time.Millisecond)
+			}
+
+			if fail {
+				return errors.New("connection reset")
+			}
+			return nil
+		}
+	}
+
+	sources := []Source[int]{
+		{Name: "orders", Run: counter(5, false)},
+		{Name: "payments", Run: counter(2, true)},
+		{Name: "shipments", Run: counter(5, false)},
+	}
+
+	envelopes, wait := TaggedFunnel(ctx, sources, WithCancelOnError())
+	for env := range envelopes {
+		if env.Err != nil {
+			fmt.Printf("%s (#%d) failed: %v\n", env.Name, env.Source, env.Err)
+			continue
+		}
+
+		fmt.Printf("%s (#%d): %d\n", env.Name, env.Source, env.Value)
+	}
+
+	fmt.Println("first error:", wait())
+}
+
+// Source is a named producer of values for TaggedFunnel. Run sends its values
+// to `out` and returns once done, or failed, or ctx is canceled.
+type Source[T any] struct {
+	Name string
+	Run  func(ctx context.Context, out chan<- T) error
+}
+
+// Envelope wraps a value produced by a source with the index and name of the
+// source. When a source fails, a last envelope with the error is emitted.
+type Envelope[T any] struct {
+	Source int
+	Name   string
+	Value  T
+	Err    error
+}
+
+// WithCancelOnError cancels all the sources of TaggedFunnel as soon as one of
+// them fails, like an errgroup does.
+func WithCancelOnError() FanOption {
+	return func(cfg *fanConfig) {
+		cfg.cancelOnError = true
+	}
+}
+
+// TaggedFunnel is a Funnel of sources telling which source each value comes
+// from and whether the source failed. The returned function waits for all the
+// sources to finish and returns the first error, if any. With
+// WithCancelOnError, the first failure cancels the rest of the sources.
+func TaggedFunnel[T any](ctx context.Context, sources []Source[T], opts ...FanOption) (<-chan Envelope[T], func() error) {
+	cfg := newFanConfig(opts)
+	dest := make(chan Envelope[T], cfg.buffer)
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+
+	var once sync.Once
+	var firstErr error
+
+	var wg sync.WaitGroup
+	wg.Add(len(sources))
+
+	for i, src := range sources {
+		go func(i int, src Source[T]) {
+			defer wg.Done()
+
+			out := make(chan T)
+			errCh := make(chan error, 1)
+
+			go func() {
+				defer close(out)
+				errCh <- src.Run(ctx, out)
+			}()
+
+			if !forwardEnvelopes(ctx, i, src.Name, out, dest) {
+				drain(out) // Source ignoring ctx, don't leave it blocked
+			}
+
+			err := <-errCh
+			if err == nil {
+				return
+			}
+
+			once.Do(func() {
+				firstErr = fmt.Errorf("source %s: %w", src.Name, err)
+				if cfg.cancelOnError {
+					cancel()
+				}
+			})
+
+			if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+				return // Stopped by the cancellation rather than failed
+			}
+
+			// The consumer reads until dest is closed, unless it gave up on the
+			// parent context, so the failure is reported even if it canceled
+			// the other sources.
+			select {
+			case dest <- Envelope[T]{Source: i, Name: src.Name, Err: err}:
+			case <-parent.Done():
+			}
+		}(i, src)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		cancel()
+		close(dest)
+		close(done)
+	}()
+
+	return dest, func() error {
+		<-done
+		return firstErr
+	}
+}
+
+// forwardEnvelopes is forward for a tagged source.
+func forwardEnvelopes[T any](ctx context.Context, i int, name string, c <-chan T, dest chan<- Envelope[T]) bool {
+	for {
+		select {
+		case v, ok := <-c:
+			if !ok {
+				return true
+			}
+
+			select {
+			case dest <- Envelope[T]{Source: i, Name: name, Value: v}:
+			case <-ctx.Done():
+				return false
+			}
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
@@ -1,24 +1,42 @@
 package patterns
 
 import (
+	"context"
 	"fmt"
+	"hash/fnv"
+	"reflect"
 	"sync"
 )
 
 func FanoutDemo() {
 	fmt.Println("Fan-out Pattern Demo...")
 
+	ctx := context.Background()
+
 	source := make(chan int)
-	dests := Split(source, 5)
+	dests := Split(ctx, source, 5)
+	go produce(source, 10)
+	printDestinations(dests)
 
-	go func() {
-		for i := 1; i <= 10; i++ {
-			source <- i
-		}
+	// With key affinity, the values with the same key (here, their parity)
+	// always go to the same Destination.
+	fmt.Println("with key affinity:")
+	source = make(chan int)
+	parity := func(v int) string { return fmt.Sprint(v % 2) }
+	dests = SplitByKey(ctx, source, 3, parity)
+	go produce(source, 10)
+	printDestinations(dests)
+}
 
-		close(source)
-	}()
+func produce(source chan<- int, n int) {
+	for i := 1; i <= n; i++ {
+		source <- i
+	}
 
+	close(source)
+}
+
+func printDestinations(dests []<-chan int) {
 	var wg sync.WaitGroup
 	wg.Add(len(dests))
 
@@ -35,29 +53,178 @@ func FanoutDemo() {
 	wg.Wait()
 }
 
+type splitStrategy int
+
+const (
+	splitCompeting splitStrategy = iota
+	splitRoundRobin
+	splitKeyAffinity
+	splitLeastLoaded
+)
+
+// WithRoundRobin makes Split hand the values to the Destinations in strict
+// turns, instead of letting the Destinations compete for them.
+func WithRoundRobin() FanOption {
+	return func(cfg *fanConfig) {
+		cfg.strategy = splitRoundRobin
+	}
+}
+
+// WithLeastLoaded makes Split hand every value to the Destination with the
+// fewest values waiting in its buffer (see WithBuffer) or, when none has room
+// left, to the first one ready to take it.
+func WithLeastLoaded() FanOption {
+	return func(cfg *fanConfig) {
+		cfg.strategy = splitLeastLoaded
+	}
+}
+
 // Fan-out is implemented as a Split function, which accepts a single Source
 // channel and an integer representing the desired number of Destination
 // channels. The Split function creates the Destination channels and executes
 // some background process that retrieves values from Source channel and
-// forwards them to one of the Destinations.
-func Split(source <-chan int, n int) []<-chan int {
-	dests := make([]<-chan int, 0)
-
-	// It will create separate goroutines for each Destiniation that compete to
-	// read the next value from Source and forward it to their respective
-	// Destination.
-	for i := 0; i < n; i++ { // Create n destination channels
-		ch := make(chan int)
-		dests = append(dests, ch)
-
-		go func() { // Each channel gets a dedicated
-			defer close(ch) // goroutine that competes for reads
-
-			for val := range source {
-				ch <- val
+// forwards them to one of the Destinations, by default the first one to get
+// it (competing consumers). The Destinations are closed once Source is, or
+// ctx is canceled. With no Destination at all, Source is left alone.
+func Split[T any](ctx context.Context, source <-chan T, n int, opts ...FanOption) []<-chan T {
+	return split(ctx, source, n, newFanConfig(opts), nil, spawnGoroutine)
+}
+
+// SplitByKey is a Split that always hands the values with the same key to the
+// same Destination, e.g. so the events of an entity are processed in order.
+// It overrides the strategy set by the options, if any.
+func SplitByKey[T any](ctx context.Context, source <-chan T, n int, key func(T) string, opts ...FanOption) []<-chan T {
+	cfg := newFanConfig(opts)
+	cfg.strategy = splitKeyAffinity
+
+	return split(ctx, source, n, cfg, key, spawnGoroutine)
+}
+
+// split implements Split and SplitByKey, `key` being only used by the latter.
+// Its goroutines are started with `spawn`, so a Pipeline can keep track of
+// them.
+func split[T any](ctx context.Context, source <-chan T, n int, cfg fanConfig, key func(T) string, spawn func(func())) []<-chan T {
+	n = max(0, n)
+
+	chans := make([]chan T, n) // Create n destination channels
+	dests := make([]<-chan T, n)
+	for i := range chans {
+		chans[i] = make(chan T, cfg.buffer)
+		dests[i] = chans[i]
+	}
+
+	if n == 0 {
+		return dests
+	}
+
+	if cfg.strategy == splitCompeting {
+		// It will create separate goroutines for each Destiniation that compete
+		// to read the next value from Source and forward it to their respective
+		// Destination.
+		for _, ch := range chans {
+			ch := ch
+			spawn(func() { // Each channel gets a dedicated
+				defer close(ch) // goroutine that competes for reads
+
+				if !forward(ctx, source, ch) && cfg.drain {
+					drain(source)
+				}
+			})
+		}
+
+		return dests
+	}
+
+	// The other strategies need a single goroutine dispatching the values.
+	spawn(func() {
+		defer func() {
+			for _, ch := range chans {
+				close(ch)
 			}
 		}()
-	}
+
+		next := 0 // Destination whose turn it is, for round-robin and ties
+		for {
+			var v T
+			var ok bool
+
+			select {
+			case v, ok = <-source:
+				if !ok {
+					return
+				}
+			case <-ctx.Done():
+				abandon(cfg, []<-chan T{source})
+				return
+			}
+
+			var sent bool
+			switch cfg.strategy {
+			case splitRoundRobin:
+				sent = send(ctx, chans[next], v)
+				next = (next + 1) % n
+			case splitKeyAffinity:
+				sent = send(ctx, chans[keyIndex(key(v), n)], v)
+			case splitLeastLoaded:
+				sent = sendLeastLoaded(ctx, chans, next, v)
+				next = (next + 1) % n
+			}
+
+			if !sent {
+				abandon(cfg, []<-chan T{source})
+				return
+			}
+		}
+	})
 
 	return dests
 }
+
+// send sends v to ch, reporting false if ctx was canceled first.
+func send[T any](ctx context.Context, ch chan<- T, v T) bool {
+	select {
+	case ch <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// keyIndex hashes the key into the index of one of n Destinations.
+func keyIndex(key string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+
+	return int(h.Sum32() % uint32(n))
+}
+
+// sendLeastLoaded sends v to the channel with the shortest queue that has
+// room for it, ties going to the first one from `start`. When all of them are
+// full, v goes to whichever takes it first.
+func sendLeastLoaded[T any](ctx context.Context, chans []chan T, start int, v T) bool {
+	best := -1
+	for n := range chans {
+		i := (start + n) % len(chans)
+		if len(chans[i]) < cap(chans[i]) && (best < 0 || len(chans[i]) < len(chans[best])) {
+			best = i
+		}
+	}
+
+	if best >= 0 {
+		return send(ctx, chans[best], v)
+	}
+
+	// Unlike reflect.ValueOf(v), which is invalid for a nil interface value,
+	// this works for whatever T is.
+	value := reflect.ValueOf(&v).Elem()
+
+	cases := make([]reflect.SelectCase, 0, len(chans)+1)
+	for _, ch := range chans {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(ch), Send: value})
+	}
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())})
+
+	chosen, _, _ := reflect.Select(cases)
+
+	return chosen < len(chans)
+}
@@ -0,0 +1,215 @@
+package patterns
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+func BroadcastDemo() {
+	fmt.Println("Broadcast Pattern Demo...")
+	ctx := context.Background()
+
+	source := make(chan int)
+	b := NewBroadcaster(ctx, source)
+
+	subscribers := map[string]*Subscription[int]{
+		"blocking":     b.Subscribe(0, PolicyBlock),
+		"drop-oldest":  b.Subscribe(2, PolicyDropOldest),
+		"drop-newest":  b.Subscribe(2, PolicyDropNewest),
+		"disconnected": b.Subscribe(2, PolicyDisconnect),
+	}
+
+	var wg sync.WaitGroup
+	for name, sub := range subscribers {
+		wg.Add(1)
+		go func(name string, sub *Subscription[int]) {
+			defer wg.Done()
+
+			var got []int
+			for v := range sub.C() {
+				got = append(got, v)
+				if name != "blocking" {
+					time.Sleep(50 * time.Millisecond) // A slow subscriber
+				}
+			}
+
+			fmt.Printf("%s got %v\n", name, got)
+		}(name, sub)
+	}
+
+	for i := 1; i <= 10; i++ {
+		source <- i
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(source)
+
+	wg.Wait()
+}
+
+// SlowSubscriberPolicy tells the Broadcaster what to do with a value when a
+// subscriber's buffer is full.
+type SlowSubscriberPolicy int
+
+const (
+	// PolicyBlock waits for the subscriber to make room, holding back every
+	// other subscriber meanwhile.
+	PolicyBlock SlowSubscriberPolicy = iota
+
+	// PolicyDropOldest discards the oldest value in the buffer to make room.
+	PolicyDropOldest
+
+	// PolicyDropNewest discards the value that doesn't fit.
+	PolicyDropNewest
+
+	// PolicyDisconnect unsubscribes the subscriber, closing its channel.
+	PolicyDisconnect
+)
+
+// Broadcaster is the publish/subscribe flavour of fan-out: unlike Split, which
+// hands each value of Source to a single Destination, every value is sent to
+// every subscriber. Subscribers can come and go at any time, each with its
+// own buffer and policy for when it can't keep up.
+type Broadcaster[T any] struct {
+	subscribe   chan *Subscription[T]
+	unsubscribe chan *Subscription[T]
+	done        chan struct{} // Closed once the broadcast is over
+}
+
+// Subscription is a subscriber of a Broadcaster.
+type Subscription[T any] struct {
+	b      *Broadcaster[T]
+	ch     chan T
+	policy SlowSubscriberPolicy
+
+	once sync.Once
+	gone chan struct{} // Closed on Unsubscribe
+}
+
+// NewBroadcaster broadcasts the values of `source` until it's closed or ctx
+// is canceled, at which point the channels of all the subscribers are closed.
+func NewBroadcaster[T any](ctx context.Context, source <-chan T) *Broadcaster[T] {
+	b := &Broadcaster[T]{
+		subscribe:   make(chan *Subscription[T]),
+		unsubscribe: make(chan *Subscription[T]),
+		done:        make(chan struct{}),
+	}
+
+	go b.run(ctx, source)
+
+	return b
+}
+
+// Subscribe adds a subscriber receiving the values broadcast from now on, with
+// a buffer of `buffer` values and the given slow subscriber policy.
+func (b *Broadcaster[T]) Subscribe(buffer int, policy SlowSubscriberPolicy) *Subscription[T] {
+	s := &Subscription[T]{
+		b:      b,
+		ch:     make(chan T, buffer),
+		policy: policy,
+		gone:   make(chan struct{}),
+	}
+
+	select {
+	case b.subscribe <- s:
+	case <-b.done: // Too late, nothing will ever be broadcast
+		close(s.ch)
+	}
+
+	return s
+}
+
+// C returns the channel the subscriber receives the values on. It's closed
+// when the subscriber is unsubscribed or the broadcast is over.
+func (s *Subscription[T]) C() <-chan T {
+	return s.ch
+}
+
+// Unsubscribe stops the subscriber from receiving any more values.
+func (s *Subscription[T]) Unsubscribe() {
+	s.once.Do(func() {
+		close(s.gone) // Release a broadcast blocked on this subscriber
+
+		select {
+		case s.b.unsubscribe <- s:
+		case <-s.b.done:
+		}
+	})
+}
+
+// run owns the set of subscribers, so it needs no locking, and is the only one
+// closing their channels, so nothing is ever sent on a closed channel.
+func (b *Broadcaster[T]) run(ctx context.Context, source <-chan T) {
+	subs := make(map[*Subscription[T]]struct{})
+
+	defer func() {
+		for s := range subs {
+			close(s.ch)
+		}
+		close(b.done)
+	}()
+
+	for {
+		select {
+		case s := <-b.subscribe:
+			subs[s] = struct{}{}
+
+		case s := <-b.unsubscribe:
+			if _, ok := subs[s]; ok {
+				delete(subs, s)
+				close(s.ch)
+			}
+
+		case v, ok := <-source:
+			if !ok {
+				return
+			}
+
+			for s := range subs {
+				if !b.deliver(ctx, s, v) {
+					delete(subs, s)
+					close(s.ch)
+				}
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// deliver sends v to the subscriber according to its policy, reporting false
+// if the subscriber must be disconnected.
+func (b *Broadcaster[T]) deliver(ctx context.Context, s *Subscription[T], v T) bool {
+	select {
+	case s.ch <- v:
+		return true
+	default:
+	}
+
+	switch s.policy {
+	case PolicyBlock:
+		select {
+		case s.ch <- v:
+		case <-s.gone:
+		case <-ctx.Done():
+		}
+
+	case PolicyDropOldest:
+		select {
+		case <-s.ch:
+		default:
+		}
+
+		select {
+		case s.ch <- v:
+		default: // The subscriber has no buffer to drop from
+		}
+
+	case PolicyDisconnect:
+		return false
+	}
+
+	return true
+}
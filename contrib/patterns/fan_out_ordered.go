@@ -0,0 +1,124 @@
+package patterns
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+func OrderedFanoutDemo() {
+	fmt.Println("Ordered Fan-out Pattern Demo...")
+	ctx := context.Background()
+
+	// Chunks of a file, uploaded concurrently but reported in order.
+	chunks := make(chan string)
+	go func() {
+		defer close(chunks)
+		for _, c := range strings.Fields("cloud native patterns in go fan out and in") {
+			chunks <- c
+		}
+	}()
+
+	upload := func(ctx context.Context, chunk string) (string, error) {
+		time.Sleep(time.Duration(rand.Intn(50)) * time.Millisecond) // Uploads take a random time
+		return fmt.Sprintf("uploaded %q", chunk), nil
+	}
+
+	for res := range OrderedMap(ctx, chunks, 3, 4, upload) {
+		fmt.Printf("#%d %s\n", res.Index, res.Value)
+	}
+}
+
+// OrderedMap runs `job` on the values of `in` with `workers` workers, like a
+// Split followed by a Funnel, but emits the results in the order of their
+// inputs. A result finished ahead of its turn is held back until the ones
+// before it are emitted, so at most `window` inputs are in flight or waiting
+// to be emitted at any time, which bounds the memory of the reorder buffer: a
+// slow input stalls the workers once they are `window` inputs ahead of it.
+// The window should be at least `workers`, otherwise some workers stay idle.
+func OrderedMap[In, Out any](ctx context.Context, in <-chan In, workers, window int, job Job[In, Out], opts ...FanOption) <-chan JobResult[In, Out] {
+	return orderedMap(ctx, in, workers, window, job, newFanConfig(opts), spawnGoroutine)
+}
+
+// orderedMap implements OrderedMap, starting its goroutines with `spawn` so a
+// Pipeline can keep track of them.
+func orderedMap[In, Out any](ctx context.Context, in <-chan In, workers, window int, job Job[In, Out], cfg fanConfig, spawn func(func())) <-chan JobResult[In, Out] {
+	out := make(chan JobResult[In, Out], cfg.buffer)
+
+	window = max(1, window)
+	slots := make(chan struct{}, window) // A slot is taken per input in flight
+
+	// Every input gets a channel its result is delivered on, queued in input
+	// order so the emitter knows whose result comes next.
+	jobs := make(chan orderedJob[In, Out])
+	queue := make(chan chan JobResult[In, Out], window)
+
+	spawn(func() { // Dispatcher
+		defer close(jobs)
+		defer close(queue)
+
+		for i := 0; ; i++ {
+			var v In
+			select {
+			case val, ok := <-in:
+				if !ok {
+					return
+				}
+				v = val
+			case <-ctx.Done():
+				abandon(cfg, []<-chan In{in})
+				return
+			}
+
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				abandon(cfg, []<-chan In{in})
+				return
+			}
+
+			result := make(chan JobResult[In, Out], 1) // Workers never block on it
+			queue <- result                            // Never blocks, holding a slot
+
+			if !send(ctx, jobs, orderedJob[In, Out]{poolJob[In]{index: i, input: v}, result}) {
+				abandon(cfg, []<-chan In{in})
+				return
+			}
+		}
+	})
+
+	// The workers compete for the jobs, as with Split, until the dispatcher
+	// closes them.
+	for n := 0; n < max(1, workers); n++ {
+		spawn(func() {
+			for j := range jobs {
+				j.result <- runJob(ctx, job, j.poolJob)
+			}
+		})
+	}
+
+	spawn(func() { // Emitter
+		defer close(out)
+
+		for result := range queue {
+			select {
+			case res := <-result:
+				if !send(ctx, out, res) {
+					return
+				}
+				<-slots
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	return out
+}
+
+type orderedJob[In, Out any] struct {
+	poolJob[In]
+	result chan<- JobResult[In, Out]
+}
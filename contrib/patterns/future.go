@@ -3,7 +3,7 @@ package patterns
 import (
 	"context"
 	"fmt"
-	"sync"
+	"runtime/debug"
 	"time"
 )
 
@@ -13,6 +13,19 @@ func FutureDemo() {
 	ctx := context.Background()
 	future := timeConsumingFunction(ctx)
 
+	if _, ok, _ := future.Poll(); !ok {
+		fmt.Println("not ready yet, doing something else meanwhile...")
+	}
+
+	// Not willing to wait for more than a second...
+	impatient, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	if _, err := future.Await(impatient); err != nil {
+		fmt.Println("gave up waiting:", err)
+	}
+
+	// ...but the future keeps going, so its result can still be awaited later.
 	res, err := future.Result()
 	if err != nil {
 		fmt.Println("error:", err)
@@ -22,59 +35,84 @@ func FutureDemo() {
 	fmt.Println(res)
 }
 
-// We declare Future which is the interface InnerFuture will satisfy
+// Future is a placeholder for the result of a function running concurrently,
+// started with Go. The result is stored once the function returns, so it can
+// be retrieved any number of times, by any number of goroutines.
+type Future[T any] struct {
+	done chan struct{} // Closed once res and err are set
 
-type Future interface {
-	Result() (string, error)
+	res T
+	err error
 }
 
-// InnerFuture has one or more methods that satisfy the Future interface, which
-// retrieve the values returned by the core function from the channels, cache
-// them, and return them. If the values aren't available ont eh channel, the
-// request blocks. If they have already been retrived, the cached values are
-// returned.
-type InnerFuture struct {
-	once sync.Once
-	wg   sync.WaitGroup
-
-	res   string
-	err   error
-	resCh <-chan string
-	errCh <-chan error
+// Go runs f in a goroutine and returns the Future of its result. ctx is
+// passed to f, which is expected to give up once it's canceled. A panic in f
+// is turned into a PanicError.
+func Go[T any](ctx context.Context, f func(context.Context) (T, error)) *Future[T] {
+	fut := &Future[T]{done: make(chan struct{})}
+
+	go func() {
+		defer close(fut.done)
+		defer func() {
+			if r := recover(); r != nil {
+				fut.err = &PanicError{Value: r, Stack: debug.Stack()}
+			}
+		}()
+
+		fut.res, fut.err = f(ctx)
+	}()
+
+	return fut
 }
 
-func (f *InnerFuture) Result() (string, error) {
-	f.once.Do(func() {
-		f.wg.Add(1)
-		defer f.wg.Done()
-		f.res = <-f.resCh
-		f.err = <-f.errCh
-	})
+// Done returns a channel closed once the result is available, to select on
+// alongside other channels.
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Poll returns the result without blocking. ok is false if it isn't
+// available yet.
+func (f *Future[T]) Poll() (res T, ok bool, err error) {
+	select {
+	case <-f.done:
+		return f.res, true, f.err
+	default:
+		return res, false, nil
+	}
+}
 
-	f.wg.Wait()
+// Await blocks until the result is available or ctx is canceled, returning
+// the context's error in the latter case. Giving up waiting doesn't stop the
+// function, whose result can still be awaited again.
+func (f *Future[T]) Await(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.res, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
 
-	return f.res, f.err
+// Result blocks until the result is available. It's Await without a way to
+// give up, for the callers that can afford to wait for as long as the
+// function takes.
+func (f *Future[T]) Result() (T, error) {
+	return f.Await(context.Background())
 }
 
 // timeConsumingFunction is a SlowFunction.
 // SlowFunction is a wrapper around the core functionality that you want to run
-// concurrently. It has the job of creating thr results channels, running the
-// core function in a goroutine, and creating and returning the Future
-// implementation. (InnerFuture, in this case)
-func timeConsumingFunction(ctx context.Context) Future {
-	resCh := make(chan string)
-	errCh := make(chan error)
-
-	go func() {
+// concurrently. With Go taking care of the goroutine and of storing the
+// results, all that's left for it is the core function itself.
+func timeConsumingFunction(ctx context.Context) *Future[string] {
+	return Go(ctx, func(ctx context.Context) (string, error) {
 		select {
 		case <-time.After(2 * time.Second):
-			resCh <- "I slept for 2 seconds"
-			errCh <- nil
+			return "I slept for 2 seconds", nil
 		case <-ctx.Done():
-			resCh <- ""
-			errCh <- ctx.Err()
+			return "", ctx.Err()
 		}
-	}()
-
-	return &InnerFuture{resCh: resCh, errCh: errCh}
+	})
 }
@@ -0,0 +1,329 @@
+package patterns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+func PipelineDemo() {
+	fmt.Println("Pipeline Pattern Demo...")
+	ctx := context.Background()
+
+	p := NewPipeline(ctx)
+
+	numbers := SourceStage(p, []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
+	squares := MapStage(p, numbers, 4, func(ctx context.Context, n int) (int, error) {
+		return n * n, nil
+	})
+	even := FilterStage(p, squares, 2, func(ctx context.Context, n int) (bool, error) {
+		return n%2 == 0, nil
+	})
+	limited := RateLimitStage(p, even, 20*time.Millisecond, 2)
+	batches := BatchStage(p, limited, 2, 100*time.Millisecond)
+
+	for batch := range batches {
+		fmt.Println(batch)
+	}
+
+	if err := p.Wait(); err != nil {
+		fmt.Println("pipeline failed:", err)
+	}
+
+	// An error in any stage short-circuits the whole pipeline.
+	p = NewPipeline(ctx)
+
+	words := SourceStage(p, []string{"fan", "in", "", "out"})
+	letters := FlatMapStage(p, words, 2, func(ctx context.Context, w string) ([]string, error) {
+		if w == "" {
+			return nil, errors.New("empty word")
+		}
+
+		return strings.Split(w, ""), nil
+	})
+
+	res, err := Collect(p, letters)
+	fmt.Printf("res %v; err %v\n", res, err)
+}
+
+// Pipeline ties together the stages of a channel pipeline. All the stages
+// share its context: the first stage to fail cancels it, with the error as
+// its cause, which stops every other stage. Each stage runs all of its
+// goroutines through the Pipeline, so Wait only returns once every one of
+// them is done.
+type Pipeline struct {
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+}
+
+func NewPipeline(ctx context.Context) *Pipeline {
+	ctx, cancel := context.WithCancelCause(ctx)
+
+	return &Pipeline{ctx: ctx, cancel: cancel}
+}
+
+// Context returns the context shared by the stages of the pipeline.
+func (p *Pipeline) Context() context.Context {
+	return p.ctx
+}
+
+// Cancel stops the pipeline.
+func (p *Pipeline) Cancel() {
+	p.cancel(context.Canceled)
+}
+
+// Wait waits for all the goroutines of the pipeline to exit, and returns the
+// error that made the pipeline fail, if any.
+func (p *Pipeline) Wait() error {
+	p.wg.Wait()
+
+	err := context.Cause(p.ctx)
+	p.cancel(nil) // Release the context's resources
+
+	return err
+}
+
+// fail cancels the pipeline because of err. Only the first error is kept.
+func (p *Pipeline) fail(err error) {
+	p.cancel(err)
+}
+
+// goStage runs f on a goroutine of the pipeline.
+func (p *Pipeline) goStage(f func()) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		f()
+	}()
+}
+
+// SourceStage starts a pipeline with the given values.
+func SourceStage[T any](p *Pipeline, values []T) <-chan T {
+	out := make(chan T)
+
+	p.goStage(func() {
+		defer close(out)
+
+		for _, v := range values {
+			if !send(p.ctx, out, v) {
+				return
+			}
+		}
+	})
+
+	return out
+}
+
+// Collect reads the output of the pipeline and waits for it to finish,
+// returning the values read and the error that made it fail, if any.
+func Collect[T any](p *Pipeline, in <-chan T) ([]T, error) {
+	var values []T
+	for v := range in {
+		values = append(values, v)
+	}
+
+	if err := p.Wait(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// MapStage applies f to every value, with `parallelism` goroutines doing so
+// concurrently. The order of the values isn't kept, see OrderedMapStage.
+func MapStage[In, Out any](p *Pipeline, in <-chan In, parallelism int, f func(context.Context, In) (Out, error)) <-chan Out {
+	return parallelStage(p, in, parallelism, func(ctx context.Context, v In, out chan<- Out) error {
+		res, err := f(ctx, v)
+		if err != nil {
+			return err
+		}
+
+		send(ctx, out, res)
+		return nil
+	})
+}
+
+// OrderedMapStage is MapStage keeping the order of the values, at the cost of
+// holding back the results finished ahead of their turn. At most `window`
+// values are in flight or held back, see OrderedMap.
+func OrderedMapStage[In, Out any](p *Pipeline, in <-chan In, parallelism, window int, f func(context.Context, In) (Out, error)) <-chan Out {
+	out := make(chan Out)
+	results := orderedMap(p.ctx, in, parallelism, window, f, fanConfig{}, p.goStage)
+
+	p.goStage(func() {
+		defer close(out)
+
+		for res := range results {
+			if res.Err != nil {
+				p.fail(res.Err)
+				continue
+			}
+
+			send(p.ctx, out, res.Value)
+		}
+	})
+
+	return out
+}
+
+// FilterStage keeps only the values for which f returns true, with
+// `parallelism` goroutines evaluating f concurrently.
+func FilterStage[T any](p *Pipeline, in <-chan T, parallelism int, f func(context.Context, T) (bool, error)) <-chan T {
+	return parallelStage(p, in, parallelism, func(ctx context.Context, v T, out chan<- T) error {
+		keep, err := f(ctx, v)
+		if err != nil {
+			return err
+		}
+
+		if keep {
+			send(ctx, out, v)
+		}
+		return nil
+	})
+}
+
+// FlatMapStage applies f to every value and emits each of the values it
+// returns, with `parallelism` goroutines doing so concurrently.
+func FlatMapStage[In, Out any](p *Pipeline, in <-chan In, parallelism int, f func(context.Context, In) ([]Out, error)) <-chan Out {
+	return parallelStage(p, in, parallelism, func(ctx context.Context, v In, out chan<- Out) error {
+		results, err := f(ctx, v)
+		if err != nil {
+			return err
+		}
+
+		for _, res := range results {
+			if !send(ctx, out, res) {
+				break
+			}
+		}
+		return nil
+	})
+}
+
+// parallelStage fans the input out with Split to `parallelism` workers, each
+// running `process` on the values it's handed, and fans their output back in
+// with Funnel. The goroutines of Split and Funnel run through the Pipeline,
+// as the workers do, so none of them outlives Wait. After the pipeline is
+// canceled, the workers keep reading their input, without processing it,
+// until Split closes it.
+func parallelStage[In, Out any](p *Pipeline, in <-chan In, parallelism int, process func(context.Context, In, chan<- Out) error) <-chan Out {
+	parallelism = max(1, parallelism)
+
+	outs := make([]<-chan Out, 0, parallelism)
+	for _, worker := range split(p.ctx, in, parallelism, fanConfig{}, nil, p.goStage) {
+		out := make(chan Out)
+		outs = append(outs, out)
+
+		worker := worker
+		p.goStage(func() {
+			defer close(out)
+
+			for v := range worker {
+				if p.ctx.Err() != nil {
+					continue
+				}
+
+				if err := process(p.ctx, v, out); err != nil {
+					p.fail(err)
+				}
+			}
+		})
+	}
+
+	return funnel(p.ctx, outs, fanConfig{}, p.goStage)
+}
+
+// BatchStage groups the values in batches of up to `size` values, emitting a
+// batch when it's full or `maxLatency` after its first value, whatever
+// happens first. It's Batch run as a stage of the pipeline.
+func BatchStage[T any](p *Pipeline, in <-chan T, size int, maxLatency time.Duration) <-chan []T {
+	out := make(chan []T)
+
+	p.goStage(func() {
+		defer close(out)
+
+		runBatch(p.ctx, in, out, size, maxLatency)
+	})
+
+	return out
+}
+
+// WindowStage groups the values in tumbling windows of `d`. It's
+// TumblingWindow run as a stage of the pipeline.
+func WindowStage[T any](p *Pipeline, in <-chan T, d time.Duration) <-chan []T {
+	out := make(chan []T)
+
+	p.goStage(func() {
+		defer close(out)
+
+		runTumblingWindow(p.ctx, in, out, d)
+	})
+
+	return out
+}
+
+// RateLimitStage lets at most one value through every `every`, with bursts of
+// up to `burst` values after quiet periods, like Throttle's token bucket: a
+// token is earned every `every`, up to `burst` of them. The values over the
+// limit wait, applying backpressure upstream.
+func RateLimitStage[T any](p *Pipeline, in <-chan T, every time.Duration, burst int) <-chan T {
+	out := make(chan T)
+
+	p.goStage(func() {
+		defer close(out)
+
+		limit := max(1, burst)
+		tokens, last := limit, time.Now()
+
+		// refill credits the tokens earned since the last one, keeping the
+		// remainder so partial periods aren't lost. A full bucket doesn't
+		// bank time.
+		refill := func(now time.Time) {
+			if earned := int(now.Sub(last) / every); earned > 0 {
+				tokens = min(limit, tokens+earned)
+				last = last.Add(time.Duration(earned) * every)
+			}
+
+			if tokens == limit {
+				last = now
+			}
+		}
+
+		for {
+			var v T
+			select {
+			case val, ok := <-in:
+				if !ok {
+					return
+				}
+				v = val
+			case <-p.ctx.Done():
+				return
+			}
+
+			refill(time.Now())
+
+			if tokens == 0 {
+				wait := time.NewTimer(time.Until(last.Add(every)))
+				select {
+				case now := <-wait.C:
+					refill(now)
+				case <-p.ctx.Done():
+					wait.Stop()
+					return
+				}
+			}
+			tokens--
+
+			if !send(p.ctx, out, v) {
+				return
+			}
+		}
+	})
+
+	return out
+}
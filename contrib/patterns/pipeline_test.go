@@ -0,0 +1,105 @@
+package patterns
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// expectNoLeak fails the test if the number of goroutines doesn't get back to
+// `before`. Goroutines that are done may take a moment to actually exit, so it
+// retries for a while, as goleak does.
+func expectNoLeak(t *testing.T, before int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<16)
+			t.Fatalf("%d goroutines left behind:\n%s", runtime.NumGoroutine()-before, buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestPipelineCancelMidStream(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	values := make([]int, 1000)
+	for i := range values {
+		values[i] = i
+	}
+
+	p := NewPipeline(context.Background())
+
+	numbers := SourceStage(p, values)
+	doubled := MapStage(p, numbers, 4, func(ctx context.Context, n int) (int, error) { return 2 * n, nil })
+	ordered := OrderedMapStage(p, doubled, 4, 8, func(ctx context.Context, n int) (int, error) { return n + 1, nil })
+	odd := FilterStage(p, ordered, 2, func(ctx context.Context, n int) (bool, error) { return n%2 == 1, nil })
+	batches := BatchStage(p, odd, 10, time.Second)
+
+	<-batches // Mid-stream: the stages are all busy
+	p.Cancel()
+
+	if err := p.Wait(); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want %v", err, context.Canceled)
+	}
+
+	expectNoLeak(t, before)
+}
+
+func TestPipelineFailureStopsStages(t *testing.T) {
+	before := runtime.NumGoroutine()
+	boom := errors.New("boom")
+
+	p := NewPipeline(context.Background())
+
+	numbers := SourceStage(p, []int{1, 2, 3, 4, 5, 6, 7, 8})
+	failing := MapStage(p, numbers, 2, func(ctx context.Context, n int) (int, error) {
+		if n == 3 {
+			return 0, boom
+		}
+		return n, nil
+	})
+	windows := WindowStage(p, failing, time.Hour)
+
+	if _, err := Collect(p, windows); !errors.Is(err, boom) {
+		t.Fatalf("got error %v, want %v", err, boom)
+	}
+
+	expectNoLeak(t, before)
+}
+
+func TestRateLimitStageBurstsAfterQuietPeriod(t *testing.T) {
+	const every, burst = 20 * time.Millisecond, 5
+
+	p := NewPipeline(context.Background())
+	defer p.Wait()
+	defer p.Cancel()
+
+	in := make(chan int)
+	out := RateLimitStage(p, in, every, burst)
+
+	pass := func(n int) time.Duration {
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			in <- i
+			<-out
+		}
+
+		return time.Since(start)
+	}
+
+	pass(burst) // Empty the bucket
+	time.Sleep(15 * every)
+
+	if elapsed := pass(burst); elapsed > 2*every {
+		t.Fatalf("a burst of %d after a quiet period took %v", burst, elapsed)
+	}
+
+	if elapsed := pass(2); elapsed < every {
+		t.Fatalf("past the burst, 2 values took %v, want at least %v", elapsed, every)
+	}
+}
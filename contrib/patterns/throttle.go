@@ -17,7 +17,12 @@ func ThrottleDemo() {
 
 	dummyEffectorWithThrottle := Throttle(dummyEffector, 3, 1, 10*time.Millisecond)
 	for i := 0; i < 20; i++ {
-		res, err := dummyEffectorWithThrottle(ctx)
+		callCtx := ctx
+		if i%5 == 0 {
+			callCtx = WithCost(ctx, 2) // Every fifth call is twice as expensive
+		}
+
+		res, err := dummyEffectorWithThrottle(callCtx)
 		if err != nil {
 			log.Printf("[ERROR] %v", err)
 		}
@@ -33,8 +38,10 @@ func ThrottleDemo() {
 // rate-limiting behaviour, The Token Bucket (https://oreil.ly/5A5aP), which
 // uses the analogy of a bucket that can hold some maximum number of tokens.
 // When a function is called, a token is taken fromt he bucket, which then
-// refills at some fixed rate.
+// refills at some fixed rate. Calls more expensive than others can declare
+// their cost with WithCost, so that many tokens are taken instead of one.
 func Throttle(e Effector, max uint, refill uint, d time.Duration) Effector {
+	var m sync.Mutex // Guards `tokens`, shared with the refill goroutine
 	var tokens = max
 	var once sync.Once
 
@@ -56,26 +63,56 @@ func Throttle(e Effector, max uint, refill uint, d time.Duration) Effector {
 						return
 
 					case <-ticker.C:
+						m.Lock()
 						t := tokens + refill
 						if t > max {
 							t = max
 						}
 						tokens = t
+						m.Unlock()
 					}
 				}
 			}()
 		})
 
-		if tokens <= 0 {
-			return "", errors.New("too many calls")
+		cost := costFromContext(ctx)
+		if cost > max {
+			return "", ErrCostExceedsCapacity
+		}
+
+		m.Lock()
+		if tokens < cost {
+			m.Unlock()
+			return "", ErrTooManyCalls
 		}
 
-		tokens--
+		tokens -= cost
+		m.Unlock()
 
 		return e(ctx)
 	}
 }
 
+// ErrCostExceedsCapacity is returned when a call costs more tokens than the
+// bucket can ever hold, so it would never be allowed through.
+var ErrCostExceedsCapacity = errors.New("call cost exceeds bucket capacity")
+
+type costKey struct{}
+
+// WithCost returns a copy of ctx declaring that the call made with it costs
+// `cost` tokens. Calls made with a context without cost take a single token.
+func WithCost(ctx context.Context, cost uint) context.Context {
+	return context.WithValue(ctx, costKey{}, cost)
+}
+
+func costFromContext(ctx context.Context) uint {
+	if cost, ok := ctx.Value(costKey{}).(uint); ok {
+		return cost
+	}
+
+	return 1
+}
+
 func dummyEffector(ctx context.Context) (string, error) {
 	return "success", nil
 }
@@ -0,0 +1,176 @@
+package patterns
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func ThrottleMiddlewareDemo() {
+	fmt.Println("Throttle Middleware Pattern Demo...")
+
+	hello := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "hello")
+	})
+
+	bucket := Bucket{Max: 3, Refill: 1, Interval: time.Second}
+	throttle := ThrottleMiddleware(NewMemoryLimiterStore(), bucket, KeyByHeader("X-API-Key"))
+	handler := throttle(hello)
+
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+		req.Header.Set("X-API-Key", "demo")
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		fmt.Printf("%d limit=%s remaining=%s reset=%s retry-after=%s %s",
+			rec.Code,
+			rec.Header().Get("RateLimit-Limit"),
+			rec.Header().Get("RateLimit-Remaining"),
+			rec.Header().Get("RateLimit-Reset"),
+			rec.Header().Get("Retry-After"),
+			rec.Body.String(),
+		)
+	}
+}
+
+// RequestKeyFunc extracts from an inbound request the key it's throttled by.
+// Requests sharing a key draw from the same bucket; requests for which the
+// key can't be extracted share the bucket of the empty key.
+type RequestKeyFunc func(r *http.Request) string
+
+// KeyByIP throttles requests by the IP address of the client connection. When
+// running behind proxies use KeyByForwardedFor instead, as every request
+// would come from the address of the last proxy.
+func KeyByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// KeyByHeader throttles requests by the value of the given header, e.g. an
+// API key. Don't use it for X-Forwarded-For, see KeyByForwardedFor.
+func KeyByHeader(name string) RequestKeyFunc {
+	return func(r *http.Request) string {
+		return strings.TrimSpace(r.Header.Get(name))
+	}
+}
+
+// KeyByForwardedFor throttles requests by the client address found in the
+// X-Forwarded-For header, for services running behind `trustedHops` proxies.
+// Every proxy appends the address it got the request from, so the entries on
+// the left are whatever the client sent and can't be trusted: the client
+// address is the one appended by the outermost trusted proxy, the
+// `trustedHops`-th entry from the right. Requests without as many entries
+// didn't go through all the proxies, and are throttled by KeyByIP.
+func KeyByForwardedFor(trustedHops int) RequestKeyFunc {
+	trustedHops = max(1, trustedHops)
+
+	return func(r *http.Request) string {
+		// The header can be split across several lines, in order.
+		var entries []string
+		for _, line := range r.Header.Values("X-Forwarded-For") {
+			entries = append(entries, strings.Split(line, ",")...)
+		}
+
+		if len(entries) < trustedHops {
+			return KeyByIP(r)
+		}
+
+		return strings.TrimSpace(entries[len(entries)-trustedHops])
+	}
+}
+
+type subjectKey struct{}
+
+// WithSubject returns a copy of ctx carrying the authenticated subject of the
+// request. It's meant to be called by the authentication middleware running
+// before ThrottleMiddleware, so requests can be throttled by KeyBySubject.
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// KeyBySubject throttles requests by the authenticated subject stored in the
+// request context with WithSubject.
+func KeyBySubject(r *http.Request) string {
+	subject, _ := r.Context().Value(subjectKey{}).(string)
+
+	return subject
+}
+
+// ThrottleMiddleware brings Throttle to inbound `net/http` traffic. Each
+// request takes tokens (one, or the cost set with WithCost on the request
+// context) from the bucket of its key in `store`. Every response carries the
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers, and the
+// requests the bucket can't afford are answered with 429 Too Many Requests, a
+// Retry-After header and a JSON problem body (RFC 7807).
+func ThrottleMiddleware(store LimiterStore, b Bucket, key RequestKeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cost := costFromContext(r.Context())
+			if cost > b.Max {
+				writeProblem(w, http.StatusRequestEntityTooLarge, ErrCostExceedsCapacity.Error())
+				return
+			}
+
+			quota, err := store.Take(r.Context(), key(r), cost, b)
+			if err != nil {
+				writeProblem(w, http.StatusServiceUnavailable, "rate limiter unavailable")
+				return
+			}
+
+			reset := strconv.FormatInt(ceilSeconds(quota.Reset), 10)
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.FormatUint(uint64(quota.Limit), 10))
+			h.Set("RateLimit-Remaining", strconv.FormatUint(uint64(quota.Remaining), 10))
+			h.Set("RateLimit-Reset", reset)
+
+			if !quota.Allowed {
+				retryAfter := strconv.FormatInt(ceilSeconds(quota.RetryAfter), 10)
+				h.Set("Retry-After", retryAfter)
+				writeProblem(w, http.StatusTooManyRequests, fmt.Sprintf("rate limit exceeded, retry in %ss", retryAfter))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// problem is the JSON body of an error response as defined by RFC 7807.
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func writeProblem(w http.ResponseWriter, status int, detail string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	})
+}
+
+// ceilSeconds rounds d up to whole seconds, as the rate limit headers can't
+// express fractions of them.
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,378 @@
+package patterns
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RedisLimiterStore is a LimiterStore speaking the Redis protocol (RESP), so
+// any Redis compatible server can hold the global quota shared by the
+// replicas. To stay away from Lua scripting it approximates the token bucket
+// with a fixed window: a window lasts as long as it takes to refill an empty
+// bucket, and allows `Max` tokens to be taken during it. Taking is done with
+// INCRBY, which is atomic on the server, and given back with DECRBY when the
+// window is already exhausted.
+type RedisLimiterStore struct {
+	addr   string
+	prefix string
+
+	lock chan struct{} // Serializes the use of the connection, see acquire
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// redisIOTimeout bounds the round trips to the server when the context of the
+// call has no earlier deadline, so a stalled server can't hang the callers.
+const redisIOTimeout = time.Second
+
+func NewRedisLimiterStore(addr string) *RedisLimiterStore {
+	return &RedisLimiterStore{addr: addr, prefix: "throttle:", lock: make(chan struct{}, 1)}
+}
+
+func (s *RedisLimiterStore) Take(ctx context.Context, key string, n uint, b Bucket) (Quota, error) {
+	if b.Interval <= 0 || b.Refill == 0 {
+		return Quota{}, errors.New("bucket must refill at a positive rate")
+	}
+	if b.Max == 0 {
+		return Quota{}, errors.New("bucket must hold at least one token")
+	}
+
+	now := time.Now()
+	window := time.Duration((b.Max+b.Refill-1)/b.Refill) * b.Interval
+	index := now.UnixNano() / int64(window)
+	windowKey := fmt.Sprintf("%s%s:%d", s.prefix, key, index)
+	reset := time.Unix(0, (index+1)*int64(window)).Sub(now)
+
+	if err := s.acquire(ctx); err != nil {
+		return Quota{}, err
+	}
+	defer s.release()
+
+	// The expiration is refreshed with every take, it only has to outlive the
+	// window so stale counters don't pile up on the server.
+	replies, err := s.do(ctx,
+		[]string{"INCRBY", windowKey, strconv.FormatUint(uint64(n), 10)},
+		[]string{"PEXPIRE", windowKey, strconv.FormatInt(window.Milliseconds(), 10)},
+	)
+	if err != nil {
+		return Quota{}, err
+	}
+
+	count := replies[0]
+	quota := Quota{Limit: b.Max, Reset: reset}
+	if count <= int64(b.Max) {
+		quota.Allowed = true
+		quota.Remaining = b.Max - uint(count)
+		return quota, nil
+	}
+
+	if _, err := s.do(ctx, []string{"DECRBY", windowKey, strconv.FormatUint(uint64(n), 10)}); err != nil {
+		return Quota{}, err
+	}
+
+	// The tokens only come back with the next window.
+	quota.RetryAfter = reset
+
+	if taken := count - int64(n); taken < int64(b.Max) {
+		quota.Remaining = b.Max - uint(taken)
+	}
+
+	return quota, nil
+}
+
+// Close releases the connection to the server, if any.
+func (s *RedisLimiterStore) Close() error {
+	s.lock <- struct{}{}
+	defer s.release()
+
+	return s.reset()
+}
+
+// acquire takes the connection, unlike a mutex giving up if ctx is done while
+// waiting for the call using it.
+func (s *RedisLimiterStore) acquire(ctx context.Context) error {
+	select {
+	case s.lock <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *RedisLimiterStore) release() {
+	<-s.lock
+}
+
+// do pipelines the commands over the connection, dialing it when needed, and
+// returns their integer replies. Must be called with the connection acquired.
+func (s *RedisLimiterStore) do(ctx context.Context, cmds ...[]string) ([]int64, error) {
+	if s.conn == nil {
+		d := net.Dialer{Timeout: redisIOTimeout}
+		conn, err := d.DialContext(ctx, "tcp", s.addr)
+		if err != nil {
+			return nil, err
+		}
+
+		s.conn, s.rd = conn, bufio.NewReader(conn)
+	}
+
+	deadline := time.Now().Add(redisIOTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	s.conn.SetDeadline(deadline)
+
+	// A deadline is all the socket understands, so canceling ctx moves it to
+	// now, interrupting the I/O in progress.
+	conn := s.conn
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	// fail resets the connection, which may be left with unread replies, and
+	// reports the cancellation of ctx rather than the I/O error it caused.
+	fail := func(err error) ([]int64, error) {
+		s.reset()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		return nil, err
+	}
+
+	var req strings.Builder
+	for _, cmd := range cmds {
+		writeRESPArray(&req, cmd)
+	}
+
+	if _, err := io.WriteString(s.conn, req.String()); err != nil {
+		return fail(err)
+	}
+
+	// Every reply is read, even after a server error, so none is left behind
+	// to be mistaken for the reply of a later command. Any other error could
+	// leave unread replies behind, so we start over.
+	replies := make([]int64, len(cmds))
+	var firstErr error
+	for i := range cmds {
+		reply, err := readRESPInteger(s.rd)
+		if err != nil {
+			var respErr respError
+			if !errors.As(err, &respErr) {
+				return fail(err)
+			}
+
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		replies[i] = reply
+	}
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return replies, nil
+}
+
+func (s *RedisLimiterStore) reset() error {
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn, s.rd = nil, nil
+
+	return err
+}
+
+// respError is an error reply sent by the server.
+type respError string
+
+func (e respError) Error() string { return string(e) }
+
+func writeRESPArray(w *strings.Builder, args []string) {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+}
+
+func readRESPLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(line, "\r\n"), nil
+}
+
+func readRESPInteger(r *bufio.Reader) (int64, error) {
+	line, err := readRESPLine(r)
+	if err != nil {
+		return 0, err
+	}
+
+	if line == "" {
+		return 0, errors.New("resp: empty reply")
+	}
+
+	switch line[0] {
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '-':
+		return 0, respError(line[1:])
+	default:
+		return 0, fmt.Errorf("resp: unexpected reply %q", line)
+	}
+}
+
+// respStandIn is a local stand-in for a Redis server implementing just the
+// commands RedisLimiterStore needs. It's meant for demos and for exercising
+// the store without a real server around.
+type respStandIn struct {
+	ln net.Listener
+
+	mu      sync.Mutex
+	values  map[string]int64
+	expires map[string]time.Time
+}
+
+func newRESPStandIn() (*respStandIn, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &respStandIn{
+		ln:      ln,
+		values:  make(map[string]int64),
+		expires: make(map[string]time.Time),
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			go s.serve(conn)
+		}
+	}()
+
+	return s, nil
+}
+
+func (s *respStandIn) Addr() string { return s.ln.Addr().String() }
+
+func (s *respStandIn) Close() error { return s.ln.Close() }
+
+func (s *respStandIn) serve(conn net.Conn) {
+	defer conn.Close()
+
+	rd := bufio.NewReader(conn)
+	for {
+		args, err := readRESPCommand(rd)
+		if err != nil {
+			return
+		}
+
+		if _, err := io.WriteString(conn, s.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *respStandIn) exec(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(args) == 0 {
+		return "-ERR empty command\r\n"
+	}
+
+	if len(args) > 1 {
+		if exp, ok := s.expires[args[1]]; ok && time.Now().After(exp) {
+			delete(s.values, args[1])
+			delete(s.expires, args[1])
+		}
+	}
+
+	switch cmd := strings.ToUpper(args[0]); {
+	case cmd == "PING":
+		return "+PONG\r\n"
+	case (cmd == "INCRBY" || cmd == "DECRBY") && len(args) == 3:
+		n, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		if cmd == "DECRBY" {
+			n = -n
+		}
+		s.values[args[1]] += n
+		return fmt.Sprintf(":%d\r\n", s.values[args[1]])
+	case cmd == "PEXPIRE" && len(args) == 3:
+		ms, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+		if _, ok := s.values[args[1]]; !ok {
+			return ":0\r\n"
+		}
+		s.expires[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		return ":1\r\n"
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+func readRESPCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readRESPLine(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil // Inline command
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		header, err := readRESPLine(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if !strings.HasPrefix(header, "$") {
+			return nil, fmt.Errorf("resp: expected bulk string, got %q", header)
+		}
+
+		size, err := strconv.Atoi(header[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, size+2) // Include the trailing CRLF
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+
+	return args, nil
+}
@@ -0,0 +1,174 @@
+package patterns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func newTestRedisLimiterStore(t *testing.T) *RedisLimiterStore {
+	t.Helper()
+
+	server, err := newRESPStandIn()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	store := NewRedisLimiterStore(server.Addr())
+	t.Cleanup(func() { store.Close() })
+
+	return store
+}
+
+func take(t *testing.T, store LimiterStore, n uint, b Bucket) Quota {
+	t.Helper()
+
+	quota, err := store.Take(context.Background(), "key", n, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return quota
+}
+
+func TestRedisLimiterStoreAllowsThenDenies(t *testing.T) {
+	store := newTestRedisLimiterStore(t)
+	b := Bucket{Max: 2, Refill: 2, Interval: time.Hour} // A single window
+
+	for remaining := uint(1); ; remaining-- {
+		quota := take(t, store, 1, b)
+		if !quota.Allowed || quota.Remaining != remaining {
+			t.Fatalf("got %+v, want allowed with %d remaining", quota, remaining)
+		}
+
+		if remaining == 0 {
+			break
+		}
+	}
+
+	quota := take(t, store, 1, b)
+	if quota.Allowed || quota.Remaining != 0 {
+		t.Fatalf("got %+v, want denied with 0 remaining", quota)
+	}
+	if quota.RetryAfter <= 0 || quota.RetryAfter > time.Hour {
+		t.Fatalf("got retry after %v, want the rest of the window", quota.RetryAfter)
+	}
+}
+
+func TestRedisLimiterStoreRefundsDeniedTakes(t *testing.T) {
+	store := newTestRedisLimiterStore(t)
+	b := Bucket{Max: 3, Refill: 3, Interval: time.Hour}
+
+	take(t, store, 2, b)
+
+	// Taking 2 more overshoots the window, the tokens are given back...
+	quota := take(t, store, 2, b)
+	if quota.Allowed || quota.Remaining != 1 {
+		t.Fatalf("got %+v, want denied with 1 remaining", quota)
+	}
+
+	// ...so the last one can still be taken.
+	if quota := take(t, store, 1, b); !quota.Allowed || quota.Remaining != 0 {
+		t.Fatalf("got %+v, want allowed with 0 remaining", quota)
+	}
+}
+
+func TestRedisLimiterStoreWindowReset(t *testing.T) {
+	store := newTestRedisLimiterStore(t)
+	b := Bucket{Max: 1, Refill: 1, Interval: 100 * time.Millisecond}
+
+	// Start at the beginning of a window, so it doesn't end mid-test.
+	time.Sleep(take(t, store, 0, b).Reset)
+
+	if quota := take(t, store, 1, b); !quota.Allowed {
+		t.Fatalf("got %+v, want allowed", quota)
+	}
+
+	quota := take(t, store, 1, b)
+	if quota.Allowed {
+		t.Fatalf("got %+v, want denied", quota)
+	}
+
+	time.Sleep(quota.RetryAfter)
+
+	if quota := take(t, store, 1, b); !quota.Allowed {
+		t.Fatalf("got %+v, want allowed in the next window", quota)
+	}
+}
+
+func TestRedisLimiterStoreRejectsInvalidBuckets(t *testing.T) {
+	store := newTestRedisLimiterStore(t)
+
+	for _, b := range []Bucket{
+		{Max: 0, Refill: 1, Interval: time.Second},
+		{Max: 1, Refill: 0, Interval: time.Second},
+		{Max: 1, Refill: 1, Interval: 0},
+	} {
+		if _, err := store.Take(context.Background(), "key", 1, b); err == nil {
+			t.Errorf("bucket %+v: got no error", b)
+		}
+	}
+}
+
+func TestRedisLimiterStoreErrorReplyKeepsConnectionInSync(t *testing.T) {
+	store := newTestRedisLimiterStore(t)
+	ctx := context.Background()
+
+	if err := store.acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer store.release()
+
+	// The reply of INCRBY comes after the error reply of the first command.
+	_, err := store.do(ctx, []string{"BOGUS"}, []string{"INCRBY", "counter", "1"})
+	var respErr respError
+	if !errors.As(err, &respErr) {
+		t.Fatalf("got error %v, want an error reply", err)
+	}
+
+	// It must not be mistaken for the reply of the next command.
+	replies, err := store.do(ctx, []string{"INCRBY", "counter", "10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replies[0] != 11 {
+		t.Fatalf("got reply %d, want 11", replies[0])
+	}
+}
+
+func TestRedisLimiterStoreCancellation(t *testing.T) {
+	// A server accepting connections but never replying.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	store := NewRedisLimiterStore(ln.Addr().String())
+	defer store.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err = store.Take(ctx, "key", 1, Bucket{Max: 1, Refill: 1, Interval: time.Second})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got error %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed >= redisIOTimeout {
+		t.Fatalf("took %v, the cancellation didn't interrupt the I/O", elapsed)
+	}
+}
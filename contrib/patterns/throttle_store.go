@@ -0,0 +1,236 @@
+package patterns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+func DistributedThrottleDemo() {
+	fmt.Println("Distributed Throttle Pattern Demo...")
+
+	ctx := context.Background()
+
+	standIn, err := newRESPStandIn()
+	if err != nil {
+		log.Printf("[ERROR] %v", err)
+		return
+	}
+	defer standIn.Close()
+
+	// Two replicas of the same service sharing one global quota of 5 calls per
+	// second through the Redis protocol compatible store.
+	bucket := Bucket{Max: 5, Refill: 5, Interval: time.Second}
+	store := NewRedisLimiterStore(standIn.Addr())
+	defer store.Close()
+
+	replicas := []Effector{
+		DistributedThrottle(dummyEffector, store, "dummy", bucket),
+		DistributedThrottle(dummyEffector, store, "dummy", bucket),
+	}
+
+	for i := 0; i < 10; i++ {
+		replica := i % len(replicas)
+		res, err := replicas[replica](ctx)
+		if err != nil {
+			log.Printf("[ERROR] replica #%d: %v", replica, err)
+			continue
+		}
+
+		fmt.Printf("replica #%d: %s\n", replica, res)
+	}
+}
+
+// Bucket describes the token bucket a LimiterStore enforces for a key. The
+// bucket holds at most Max tokens and gets Refill tokens back every Interval.
+type Bucket struct {
+	Max      uint
+	Refill   uint
+	Interval time.Duration
+}
+
+// Quota is the outcome of taking tokens from a bucket.
+type Quota struct {
+	Allowed    bool          // Whether the tokens were taken
+	Limit      uint          // Capacity of the bucket
+	Remaining  uint          // Tokens left after the take
+	Reset      time.Duration // Time until the bucket is full again
+	RetryAfter time.Duration // When not allowed, time until the tokens asked for are available
+}
+
+// LimiterStore keeps the state of the token buckets outside of the Throttle
+// closure, so several replicas of a service can share a single global quota
+// instead of each one enforcing its own. Take must refill the bucket for the
+// elapsed time and take `n` tokens from it as a single atomic operation.
+type LimiterStore interface {
+	Take(ctx context.Context, key string, n uint, b Bucket) (Quota, error)
+}
+
+// ErrTooManyCalls is returned by the throttled functions when the bucket has
+// run out of tokens.
+var ErrTooManyCalls = errors.New("too many calls")
+
+// DistributedThrottle works like Throttle, except that the tokens are kept in
+// `store` under `key`. Every function throttled with the same store and key,
+// in this process or any other, draws from the same bucket.
+func DistributedThrottle(e Effector, store LimiterStore, key string, b Bucket) Effector {
+	return func(ctx context.Context) (string, error) {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+
+		cost := costFromContext(ctx)
+		if cost > b.Max {
+			return "", ErrCostExceedsCapacity
+		}
+
+		quota, err := store.Take(ctx, key, cost, b)
+		if err != nil {
+			return "", fmt.Errorf("limiter store: %w", err)
+		}
+
+		if !quota.Allowed {
+			return "", ErrTooManyCalls
+		}
+
+		return e(ctx)
+	}
+}
+
+// DistributedThrottleWait is the patient version of DistributedThrottle:
+// rather than rejecting a call the bucket can't afford yet, it waits for the
+// bucket to refill until the call's cost can be taken or ctx is done.
+func DistributedThrottleWait(e Effector, store LimiterStore, key string, b Bucket) Effector {
+	return func(ctx context.Context) (string, error) {
+		cost := costFromContext(ctx)
+		if cost > b.Max {
+			return "", ErrCostExceedsCapacity
+		}
+
+		for {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+
+			quota, err := store.Take(ctx, key, cost, b)
+			if err != nil {
+				return "", fmt.Errorf("limiter store: %w", err)
+			}
+
+			if quota.Allowed {
+				return e(ctx)
+			}
+
+			// Tokens come back every interval at the latest, check again then.
+			wait := b.Interval
+			if quota.RetryAfter > 0 && quota.RetryAfter < wait {
+				wait = quota.RetryAfter
+			}
+
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+	}
+}
+
+// MemoryLimiterStore is a LimiterStore local to the process. Instead of
+// running a ticker per bucket, the tokens are refilled lazily on every Take
+// from the time elapsed since the last refill. A bucket left alone long
+// enough to be full again is no different from a new one, so such buckets
+// are evicted, sweeping them at most every minute on Take, and the store
+// doesn't grow with every key ever seen, e.g. every client address.
+type MemoryLimiterStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	tokens     uint
+	lastRefill time.Time
+	fullAt     time.Time // When it's full again if left alone, zero if never
+}
+
+// memoryStoreSweepEvery is how often MemoryLimiterStore looks for buckets to
+// evict.
+const memoryStoreSweepEvery = time.Minute
+
+func NewMemoryLimiterStore() *MemoryLimiterStore {
+	return &MemoryLimiterStore{buckets: make(map[string]*memoryBucket)}
+}
+
+func (s *MemoryLimiterStore) Take(ctx context.Context, key string, n uint, b Bucket) (Quota, error) {
+	if b.Interval <= 0 {
+		return Quota{}, errors.New("bucket interval must be positive")
+	}
+
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= memoryStoreSweepEvery {
+		for k, bucket := range s.buckets {
+			if !bucket.fullAt.IsZero() && !now.Before(bucket.fullAt) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: b.Max, lastRefill: now}
+		s.buckets[key] = bucket
+	}
+
+	// Add the tokens of every whole interval elapsed since the last refill,
+	// keeping the remainder so partial intervals aren't lost.
+	intervals := now.Sub(bucket.lastRefill) / b.Interval
+	if intervals > 0 {
+		bucket.tokens = min(b.Max, bucket.tokens+uint(intervals)*b.Refill)
+		bucket.lastRefill = bucket.lastRefill.Add(intervals * b.Interval)
+	}
+
+	quota := Quota{Limit: b.Max}
+	if bucket.tokens >= n {
+		bucket.tokens -= n
+		quota.Allowed = true
+	}
+
+	quota.Remaining = bucket.tokens
+	if b.Refill > 0 {
+		// untilHolding is the time until the bucket holds `tokens` tokens.
+		untilHolding := func(tokens uint) time.Duration {
+			missing := tokens - min(tokens, bucket.tokens)
+			if missing == 0 {
+				return 0
+			}
+
+			needed := time.Duration((missing + b.Refill - 1) / b.Refill)
+			return max(0, needed*b.Interval-now.Sub(bucket.lastRefill))
+		}
+
+		quota.Reset = untilHolding(b.Max)
+		if !quota.Allowed {
+			quota.RetryAfter = untilHolding(n)
+		}
+	}
+
+	switch {
+	case bucket.tokens >= b.Max:
+		bucket.fullAt = now
+	case b.Refill > 0:
+		bucket.fullAt = now.Add(quota.Reset)
+	default: // Never refilled, so it must be kept
+		bucket.fullAt = time.Time{}
+	}
+
+	return quota, nil
+}
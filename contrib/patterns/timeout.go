@@ -2,7 +2,10 @@ package patterns
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,10 +18,22 @@ func TimeoutDemo() {
 
 	fmt.Println("Waiting response from long running function...")
 	go spinner(100 * time.Millisecond)
-	dummySlowFunctionWithTimeout := Timeout(dummySlowFunction)
+
+	var abandoned atomic.Int64
+	dummySlowFunctionWithTimeout := Timeout(dummySlowFunction,
+		WithAbandonedCount(&abandoned),
+		WithMaxAbandoned(1),
+		WithOnAbandon(func(res string, err error) {
+			log.Printf("abandoned call returned late: res %q; err %v", res, err)
+		}),
+	)
 	res, err := dummySlowFunctionWithTimeout(ctxt, "slowly returned")
 
 	fmt.Printf("\rres %q; err %v\n", res, err)
+	fmt.Printf("abandoned calls still running: %d\n", abandoned.Load())
+
+	_, err = dummySlowFunctionWithTimeout(ctx, "rejected")
+	fmt.Printf("next call: err %v\n", err)
 }
 
 // We beging by creating an SlowFunction type that specifies the signature of the
@@ -28,32 +43,135 @@ type SlowFunction func(string) (string, error)
 
 type WithContext func(context.Context, string) (string, error)
 
+// TimeoutOption customizes the behaviour of Timeout.
+type TimeoutOption func(*timeoutConfig)
+
+type timeoutConfig struct {
+	abandoned    *atomic.Int64
+	maxAbandoned int64
+	onAbandon    any // func(R, error), R being the result type of the wrapped function
+}
+
+// newTimeoutConfig applies the options for a function whose result type is
+// R. It panics if the cleanup set by WithOnAbandon is for another type, so
+// the mismatch is caught when wrapping the function rather than by a cleanup
+// silently never running.
+func newTimeoutConfig[R any](opts []TimeoutOption) *timeoutConfig {
+	cfg := &timeoutConfig{abandoned: new(atomic.Int64)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.onAbandon != nil {
+		if _, ok := cfg.onAbandon.(func(R, error)); !ok {
+			panic(fmt.Sprintf("WithOnAbandon: cleanup is %T, want %T", cfg.onAbandon, (func(R, error))(nil)))
+		}
+	}
+
+	return cfg
+}
+
+// WithAbandonedCount reports in `n` how many calls, whose callers gave up on
+// them, are still running. Several wrappers can share the same counter.
+func WithAbandonedCount(n *atomic.Int64) TimeoutOption {
+	return func(cfg *timeoutConfig) {
+		cfg.abandoned = n
+	}
+}
+
+// WithMaxAbandoned rejects new calls with ErrTooManyAbandoned while `n`
+// abandoned calls are still running, so a hung dependency can't make them
+// pile up without bound. Only the abandoned calls count, the healthy ones
+// aren't limited in any way. The calls already running when the cap is
+// reached can still be abandoned afterwards, so the count can go past `n` by
+// at most the number of calls that were in flight at that moment.
+func WithMaxAbandoned(n int64) TimeoutOption {
+	return func(cfg *timeoutConfig) {
+		cfg.maxAbandoned = n
+	}
+}
+
+// WithOnAbandon registers a cleanup for the late results of abandoned calls,
+// e.g. to close a resource nobody is going to use. It's run on the goroutine
+// of the call once it returns. R must match the result type of the wrapped
+// function, `struct{}` for the ones returning no result, or wrapping the
+// function panics.
+func WithOnAbandon[R any](cleanup func(res R, err error)) TimeoutOption {
+	return func(cfg *timeoutConfig) {
+		cfg.onAbandon = cleanup
+	}
+}
+
+// ErrTooManyAbandoned is returned by Timeout when the maximum of abandoned
+// calls still running set by WithMaxAbandoned was reached.
+var ErrTooManyAbandoned = errors.New("too many abandoned calls still running")
+
+// States of a call wrapped by Timeout.
+const (
+	callRunning int32 = iota
+	callReturned
+	callAbandoned
+)
+
 // This pattern is particularly useful whenever the function it's trying to be
 // invoke is from third party dependencies and its signature is locked to be
-// updated in order to accept a `context.Context`.
-func Timeout(f SlowFunction) WithContext {
-	return func(ctx context.Context, arg string) (string, error) {
-		chres := make(chan string)
-		cherr := make(chan error)
-
-		go func() {
-			res, err := f(arg)
-			chres <- res
-			cherr <- err
-		}()
-
-		select {
-		case res := <-chres:
-			return res, <-cherr
-		case <-ctx.Done():
-			return "", ctx.Err()
-
-			// Although it's usually preferred to implement service timeouts using
-			// context.Context, channel timeouts can also be implemented using the
-			// channel provided by the `time.After` function. Like follows...
-			// case <-time.After(10 * time.Second):
-			// 	return "", errors.New("timed out")
+// updated in order to accept a `context.Context`. As such function can't be
+// canceled, it keeps running after the caller gave up on it; the result
+// channel is buffered so it can still deliver its result and exit instead of
+// leaking its goroutine. See TimeoutFunc1 and friends for functions with any
+// other signature.
+func Timeout(f SlowFunction, opts ...TimeoutOption) WithContext {
+	return TimeoutFunc1(f, opts...)
+}
+
+// runWithTimeout is the core of all the Timeout adapters: it runs `f` on its
+// own goroutine and waits for it to return until ctx is done.
+func runWithTimeout[R any](ctx context.Context, cfg *timeoutConfig, f func() (R, error)) (R, error) {
+	var zero R
+
+	if cfg.maxAbandoned > 0 && cfg.abandoned.Load() >= cfg.maxAbandoned {
+		return zero, ErrTooManyAbandoned
+	}
+
+	type result struct {
+		res R
+		err error
+	}
+
+	ch := make(chan result, 1)
+	var state atomic.Int32 // Settles who, caller or call, finishes first
+
+	go func() {
+		res, err := f()
+		ch <- result{res, err}
+
+		if !state.CompareAndSwap(callRunning, callReturned) { // Caller gave up
+			cfg.abandoned.Add(-1)
+			if cfg.onAbandon != nil { // Its type was checked by newTimeoutConfig
+				cfg.onAbandon.(func(R, error))(res, err)
+			}
 		}
+	}()
+
+	select {
+	case r := <-ch:
+		return r.res, r.err
+	case <-ctx.Done():
+		cfg.abandoned.Add(1)
+		if state.CompareAndSwap(callRunning, callAbandoned) {
+			return zero, ctx.Err()
+		}
+
+		// The call returned right as we were giving up on it.
+		cfg.abandoned.Add(-1)
+		r := <-ch
+		return r.res, r.err
+
+		// Although it's usually preferred to implement service timeouts using
+		// context.Context, channel timeouts can also be implemented using the
+		// channel provided by the `time.After` function. Like follows...
+		// case <-time.After(10 * time.Second):
+		// 	return "", errors.New("timed out")
 	}
 }
 
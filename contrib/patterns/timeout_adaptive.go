@@ -0,0 +1,191 @@
+package patterns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+)
+
+func AdaptiveTimeoutDemo() {
+	fmt.Println("Adaptive Timeout Pattern Demo...")
+	ctx := context.Background()
+
+	// A dependency usually answering in 10-30ms, with a rare hiccup of 500ms.
+	jitteryEffector := func(ctx context.Context) (string, error) {
+		latency := time.Duration(10+rand.Intn(20)) * time.Millisecond
+		if rand.Intn(50) == 0 {
+			latency = 500 * time.Millisecond
+		}
+
+		select {
+		case <-time.After(latency):
+			return fmt.Sprintf("answered in %v", latency), nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	adaptive := NewAdaptiveTimeout(jitteryEffector,
+		WithPercentile(0.95),
+		WithMultiplier(2),
+		WithTimeoutBounds(20*time.Millisecond, time.Second),
+		WithLatencyWindow(50),
+	)
+
+	for i := 0; i < 100; i++ {
+		_, err := adaptive.Do(ctx)
+		if err != nil {
+			log.Printf("[ERROR] %v", err)
+		}
+
+		if i%10 == 9 {
+			fmt.Printf("timeout after %d calls: %v\n", i+1, adaptive.Current())
+		}
+	}
+}
+
+// AdaptiveTimeout gives the calls to an Effector a timeout that follows the
+// real behaviour of the dependency behind it, rather than a fixed one that is
+// either too tight or too loose. It keeps the latencies of the last calls and
+// times the calls out at a percentile of them times a multiplier, bounded by
+// a minimum and a maximum. Until enough latencies are observed, the maximum is
+// used. A call that timed out only tells its latency was at least the
+// timeout, so it's recorded as such: when the dependency slows down past the
+// timeout, those samples push the percentile up to the timeout, which then
+// grows by the multiplier until the calls succeed again.
+type AdaptiveTimeout struct {
+	e   Effector
+	cfg adaptiveTimeoutConfig
+
+	mu        sync.Mutex
+	latencies []time.Duration // Ring buffer of the last latencies
+	next      int             // Position of the next latency in the ring
+	sinceCalc int             // Latencies observed since `current` was computed
+	current   time.Duration
+}
+
+// AdaptiveTimeoutOption customizes the behaviour of AdaptiveTimeout.
+type AdaptiveTimeoutOption func(*adaptiveTimeoutConfig)
+
+type adaptiveTimeoutConfig struct {
+	percentile float64
+	multiplier float64
+	min, max   time.Duration
+	window     int
+	minSamples int
+}
+
+// WithPercentile sets the percentile, between 0 and 1, of the observed
+// latencies the timeout is based on. Defaults to 0.99.
+func WithPercentile(p float64) AdaptiveTimeoutOption {
+	return func(cfg *adaptiveTimeoutConfig) {
+		cfg.percentile = p
+	}
+}
+
+// WithMultiplier sets by how much the percentile latency is multiplied to get
+// the timeout. Defaults to 2.
+func WithMultiplier(m float64) AdaptiveTimeoutOption {
+	return func(cfg *adaptiveTimeoutConfig) {
+		cfg.multiplier = m
+	}
+}
+
+// WithTimeoutBounds bounds the timeout. Defaults to between 10ms and 10s.
+func WithTimeoutBounds(lower, upper time.Duration) AdaptiveTimeoutOption {
+	return func(cfg *adaptiveTimeoutConfig) {
+		cfg.min, cfg.max = lower, upper
+	}
+}
+
+// WithLatencyWindow sets how many of the last latencies are kept. At least a
+// fifth of them must be observed before the timeout adapts. Defaults to 1000.
+func WithLatencyWindow(n int) AdaptiveTimeoutOption {
+	return func(cfg *adaptiveTimeoutConfig) {
+		cfg.window = n
+	}
+}
+
+func NewAdaptiveTimeout(e Effector, opts ...AdaptiveTimeoutOption) *AdaptiveTimeout {
+	cfg := adaptiveTimeoutConfig{
+		percentile: 0.99,
+		multiplier: 2,
+		min:        10 * time.Millisecond,
+		max:        10 * time.Second,
+		window:     1000,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	cfg.window = max(1, cfg.window)
+	cfg.minSamples = max(1, cfg.window/5)
+
+	return &AdaptiveTimeout{
+		e:         e,
+		cfg:       cfg,
+		latencies: make([]time.Duration, 0, cfg.window),
+		current:   cfg.max,
+	}
+}
+
+// Do calls the Effector with the current timeout. Timing out is reported with
+// ErrAttemptTimeout, or ErrOverallDeadline if ctx ran out of time first.
+func (t *AdaptiveTimeout) Do(ctx context.Context) (string, error) {
+	start := time.Now()
+	timeout := t.Current()
+
+	res, err := attemptWithTimeout(ctx, t.e, timeout)
+	switch {
+	case err == nil:
+		t.observe(time.Since(start))
+	case errors.Is(err, ErrAttemptTimeout):
+		t.observe(timeout) // The real latency is unknown, but at least that
+	}
+
+	return res, err
+}
+
+// Current returns the timeout the next call will be given.
+func (t *AdaptiveTimeout) Current() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.current
+}
+
+// observe records the latency of a call. To spare sorting the window on every
+// call, the timeout is recomputed once every tenth of the window has been
+// renewed.
+func (t *AdaptiveTimeout) observe(latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.latencies) < t.cfg.window {
+		t.latencies = append(t.latencies, latency)
+	} else {
+		t.latencies[t.next] = latency
+	}
+	t.next = (t.next + 1) % t.cfg.window
+	t.sinceCalc++
+
+	if len(t.latencies) < t.cfg.minSamples || t.sinceCalc < max(1, t.cfg.window/10) {
+		return
+	}
+	t.sinceCalc = 0
+
+	sorted := slices.Clone(t.latencies)
+	slices.Sort(sorted)
+
+	rank := int(math.Ceil(t.cfg.percentile*float64(len(sorted)))) - 1
+	rank = min(max(rank, 0), len(sorted)-1)
+
+	timeout := time.Duration(float64(sorted[rank]) * t.cfg.multiplier)
+	t.current = min(max(timeout, t.cfg.min), t.cfg.max)
+}
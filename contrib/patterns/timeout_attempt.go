@@ -0,0 +1,101 @@
+package patterns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func AttemptTimeoutDemo() {
+	fmt.Println("Attempt Timeout Pattern Demo...")
+	ctx := context.Background()
+
+	// Each attempt gets 100ms, and all of them together 500ms.
+	hangingEffectorWithTimeouts := OverallTimeout(
+		Retry(AttemptTimeout(hangingEffector, 100*time.Millisecond), 10, 50*time.Millisecond),
+		500*time.Millisecond,
+	)
+
+	res, err := hangingEffectorWithTimeouts(ctx)
+
+	fmt.Printf("res %q; err %v\n", res, err)
+	fmt.Println("attempt timed out:", errors.Is(err, ErrAttemptTimeout))
+	fmt.Println("overall deadline exceeded:", errors.Is(err, ErrOverallDeadline))
+}
+
+var (
+	// ErrAttemptTimeout is returned by AttemptTimeout when an attempt ran out
+	// of its own time, while there is still time left to try again.
+	ErrAttemptTimeout = errors.New("attempt timed out")
+
+	// ErrOverallDeadline is returned when the deadline of the whole operation,
+	// as set by OverallTimeout or by the caller's context, is exceeded.
+	ErrOverallDeadline = errors.New("overall deadline exceeded")
+)
+
+// AttemptTimeout gives every call to `e` a deadline of its own, `d` from the
+// start of the call. It's meant to be wrapped by Retry, so a hung attempt is
+// abandoned and retried instead of using up the whole time of the operation.
+// The deadline of the attempt is clamped to the one of the caller's context,
+// in which case running out of time is reported as ErrOverallDeadline rather
+// than ErrAttemptTimeout. Both errors also match context.DeadlineExceeded.
+func AttemptTimeout(e Effector, d time.Duration) Effector {
+	return func(ctx context.Context) (string, error) {
+		return attemptWithTimeout(ctx, e, d)
+	}
+}
+
+func attemptWithTimeout(ctx context.Context, e Effector, d time.Duration) (string, error) {
+	attemptCtx, cancel := context.WithTimeout(ctx, d) // Clamped to ctx's deadline
+	defer cancel()
+
+	res, err := e(attemptCtx)
+	if err == nil || attemptCtx.Err() == nil {
+		return res, err
+	}
+
+	if overallDeadlineExceeded(ctx) {
+		return res, fmt.Errorf("%w: %w", ErrOverallDeadline, context.DeadlineExceeded)
+	}
+
+	if ctx.Err() != nil { // Canceled by the caller
+		return res, ctx.Err()
+	}
+
+	return res, fmt.Errorf("%w after %v: %w", ErrAttemptTimeout, d, context.DeadlineExceeded)
+}
+
+// OverallTimeout sets the deadline of the whole operation performed by `e`,
+// retries included, to `d` from the start of the call.
+func OverallTimeout(e Effector, d time.Duration) Effector {
+	return func(ctx context.Context) (string, error) {
+		overallCtx, cancel := context.WithTimeout(ctx, d)
+		defer cancel()
+
+		res, err := e(overallCtx)
+		if err == nil || errors.Is(err, ErrOverallDeadline) || !overallDeadlineExceeded(overallCtx) {
+			return res, err
+		}
+
+		return res, fmt.Errorf("%w: %w", ErrOverallDeadline, err)
+	}
+}
+
+// overallDeadlineExceeded tells whether ctx ran out of time. It compares the
+// deadline to the current time too, as the context may not have noticed yet.
+func overallDeadlineExceeded(ctx context.Context) bool {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return true
+	}
+
+	deadline, ok := ctx.Deadline()
+
+	return ok && !time.Now().Before(deadline)
+}
+
+func hangingEffector(ctx context.Context) (string, error) {
+	<-ctx.Done()
+
+	return "", ctx.Err()
+}
@@ -0,0 +1,120 @@
+package patterns
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+func TimeoutFuncDemo() {
+	fmt.Println("Timeout Func Pattern Demo...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	// Any third party function, whatever its signature, can be given a
+	// context to time out with.
+	hostname := TimeoutFunc0(os.Hostname)
+	name, err := hostname(ctx)
+	fmt.Printf("hostname %q; err %v\n", name, err)
+
+	sleep := TimeoutVoid1(time.Sleep)
+	err = sleep(ctx, time.Second)
+	fmt.Printf("sleep err %v\n", err)
+}
+
+// The Timeout adapters below give a context to functions of any signature:
+// from zero to three arguments, returning a result and an error (Func), only
+// an error (Err), or nothing at all (Void). The adapted functions return
+// ctx.Err() if ctx is done before the wrapped function returns.
+
+func TimeoutFunc0[R any](f func() (R, error), opts ...TimeoutOption) func(context.Context) (R, error) {
+	cfg := newTimeoutConfig[R](opts)
+
+	return func(ctx context.Context) (R, error) {
+		return runWithTimeout(ctx, cfg, f)
+	}
+}
+
+func TimeoutFunc1[A, R any](f func(A) (R, error), opts ...TimeoutOption) func(context.Context, A) (R, error) {
+	cfg := newTimeoutConfig[R](opts)
+
+	return func(ctx context.Context, a A) (R, error) {
+		return runWithTimeout(ctx, cfg, func() (R, error) { return f(a) })
+	}
+}
+
+func TimeoutFunc2[A, B, R any](f func(A, B) (R, error), opts ...TimeoutOption) func(context.Context, A, B) (R, error) {
+	cfg := newTimeoutConfig[R](opts)
+
+	return func(ctx context.Context, a A, b B) (R, error) {
+		return runWithTimeout(ctx, cfg, func() (R, error) { return f(a, b) })
+	}
+}
+
+func TimeoutFunc3[A, B, C, R any](f func(A, B, C) (R, error), opts ...TimeoutOption) func(context.Context, A, B, C) (R, error) {
+	cfg := newTimeoutConfig[R](opts)
+
+	return func(ctx context.Context, a A, b B, c C) (R, error) {
+		return runWithTimeout(ctx, cfg, func() (R, error) { return f(a, b, c) })
+	}
+}
+
+func TimeoutErr0(f func() error, opts ...TimeoutOption) func(context.Context) error {
+	cfg := newTimeoutConfig[struct{}](opts)
+
+	return func(ctx context.Context) error {
+		return runErrWithTimeout(ctx, cfg, f)
+	}
+}
+
+func TimeoutErr1[A any](f func(A) error, opts ...TimeoutOption) func(context.Context, A) error {
+	cfg := newTimeoutConfig[struct{}](opts)
+
+	return func(ctx context.Context, a A) error {
+		return runErrWithTimeout(ctx, cfg, func() error { return f(a) })
+	}
+}
+
+func TimeoutErr2[A, B any](f func(A, B) error, opts ...TimeoutOption) func(context.Context, A, B) error {
+	cfg := newTimeoutConfig[struct{}](opts)
+
+	return func(ctx context.Context, a A, b B) error {
+		return runErrWithTimeout(ctx, cfg, func() error { return f(a, b) })
+	}
+}
+
+func TimeoutErr3[A, B, C any](f func(A, B, C) error, opts ...TimeoutOption) func(context.Context, A, B, C) error {
+	cfg := newTimeoutConfig[struct{}](opts)
+
+	return func(ctx context.Context, a A, b B, c C) error {
+		return runErrWithTimeout(ctx, cfg, func() error { return f(a, b, c) })
+	}
+}
+
+func TimeoutVoid0(f func(), opts ...TimeoutOption) func(context.Context) error {
+	return TimeoutErr0(func() error { f(); return nil }, opts...)
+}
+
+func TimeoutVoid1[A any](f func(A), opts ...TimeoutOption) func(context.Context, A) error {
+	return TimeoutErr1(func(a A) error { f(a); return nil }, opts...)
+}
+
+func TimeoutVoid2[A, B any](f func(A, B), opts ...TimeoutOption) func(context.Context, A, B) error {
+	return TimeoutErr2(func(a A, b B) error { f(a, b); return nil }, opts...)
+}
+
+func TimeoutVoid3[A, B, C any](f func(A, B, C), opts ...TimeoutOption) func(context.Context, A, B, C) error {
+	return TimeoutErr3(func(a A, b B, c C) error { f(a, b, c); return nil }, opts...)
+}
+
+// runErrWithTimeout runs the functions returning only an error, which are
+// seen by the OnAbandon cleanups as returning `struct{}`.
+func runErrWithTimeout(ctx context.Context, cfg *timeoutConfig, f func() error) error {
+	_, err := runWithTimeout(ctx, cfg, func() (struct{}, error) {
+		return struct{}{}, f()
+	})
+
+	return err
+}
@@ -0,0 +1,136 @@
+package patterns
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+)
+
+func DeadlinePropagationDemo() {
+	fmt.Println("Deadline Propagation Pattern Demo...")
+
+	// The downstream service reports how much time it was given.
+	downstream := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok := r.Context().Deadline()
+		if !ok {
+			fmt.Fprintln(w, "no deadline")
+			return
+		}
+
+		fmt.Fprintf(w, "deadline in %v\n", time.Until(deadline).Round(time.Millisecond))
+	})
+
+	server := httptest.NewServer(PropagateDeadline(5 * time.Second)(downstream))
+	defer server.Close()
+
+	client := &http.Client{Transport: &DeadlineTransport{Margin: 50 * time.Millisecond}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if err != nil {
+		log.Printf("[ERROR] %v", err)
+		return
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		log.Printf("[ERROR] %v", err)
+		return
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(res.Body)
+	fmt.Printf("caller gave 500ms, downstream says: %s", body)
+}
+
+// DeadlineHeader carries across HTTP calls how many milliseconds the caller
+// is still willing to wait for the response. Sending the remaining time rather
+// than the deadline itself keeps the clocks of both ends out of the equation.
+const DeadlineHeader = "X-Request-Timeout-Ms"
+
+// DeadlineTransport is an `http.RoundTripper` propagating the deadline of the
+// request's context to the server in the DeadlineHeader, so it can stop
+// working on the request once the caller gave up on it. The Margin is taken
+// off the remaining time to account for the time spent in transit. Requests
+// with no time left aren't sent at all.
+type DeadlineTransport struct {
+	Base   http.RoundTripper // http.DefaultTransport when nil
+	Margin time.Duration
+}
+
+func (t *DeadlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	deadline, ok := req.Context().Deadline()
+	if !ok {
+		return base.RoundTrip(req)
+	}
+
+	remaining := time.Until(deadline) - t.Margin
+	if remaining <= 0 {
+		if req.Body != nil {
+			req.Body.Close() // As the RoundTripper contract requires
+		}
+		return nil, fmt.Errorf("no time left to call %s: %w", req.URL.Host, context.DeadlineExceeded)
+	}
+
+	// RoundTrippers must not modify the request they are given.
+	req = req.Clone(req.Context())
+	req.Header.Set(DeadlineHeader, strconv.FormatInt(remaining.Milliseconds(), 10))
+
+	return base.RoundTrip(req)
+}
+
+// PropagateDeadline is the server side of DeadlineTransport: it reconstructs
+// the caller's deadline from the DeadlineHeader into the request context, so
+// Timeout, Retry and everything else using that context stop when the caller
+// is no longer waiting. Requests arriving with no time left are answered with
+// 504 Gateway Timeout straight away. `limit`, when positive, caps the time a
+// caller can ask for.
+func PropagateDeadline(limit time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get(DeadlineHeader)
+			if header == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ms, err := strconv.ParseInt(header, 10, 64)
+			if err != nil {
+				next.ServeHTTP(w, r) // Not ours to judge, carry on without deadline
+				return
+			}
+
+			if ms <= 0 {
+				writeProblem(w, http.StatusGatewayTimeout, "caller deadline already exceeded")
+				return
+			}
+
+			// Clamped before converting, a huge value would overflow into a
+			// negative timeout.
+			ms = min(ms, math.MaxInt64/int64(time.Millisecond))
+
+			timeout := time.Duration(ms) * time.Millisecond
+			if limit > 0 && timeout > limit {
+				timeout = limit
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package patterns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+func WorkerPoolDemo() {
+	fmt.Println("Worker Pool Pattern Demo...")
+	ctx := context.Background()
+
+	shout := func(ctx context.Context, word string) (string, error) {
+		time.Sleep(time.Duration(len(word)) * 10 * time.Millisecond) // Longer words take longer
+		switch word {
+		case "":
+			return "", errors.New("nothing to shout")
+		case "boom":
+			panic("exploded")
+		}
+
+		return strings.ToUpper(word), nil
+	}
+
+	words := []string{"cloud", "native", "", "go", "boom", "patterns"}
+	for _, res := range ProcessAll(ctx, words, 3, shout) {
+		if res.Err != nil {
+			fmt.Printf("#%d %q: error %v\n", res.Index, res.Input, res.Err)
+			continue
+		}
+
+		fmt.Printf("#%d %q: %s\n", res.Index, res.Input, res.Value)
+	}
+
+	// A long-running pool, streaming its results in submission order, that
+	// is gracefully shut down once there is nothing else to submit.
+	fmt.Println("streaming in order:")
+	pool := NewWorkerPool(ctx, 3, shout, WithOrderedResults())
+
+	go func() {
+		for _, word := range []string{"pipelines", "fan", "out", "and", "in"} {
+			if err := pool.Submit(ctx, word); err != nil {
+				log.Printf("[ERROR] %v", err)
+			}
+		}
+
+		if err := pool.Shutdown(ctx); err != nil {
+			log.Printf("[ERROR] %v", err)
+		}
+	}()
+
+	for res := range pool.Results() {
+		fmt.Printf("#%d %q: %s\n", res.Index, res.Input, res.Value)
+	}
+}
+
+// Job is the function run by the workers of a WorkerPool for every input.
+type Job[In, Out any] func(ctx context.Context, in In) (Out, error)
+
+// JobResult is the outcome of the job run for an input.
+type JobResult[In, Out any] struct {
+	Index int // Position of the input in submission order
+	Input In
+	Value Out
+	Err   error
+}
+
+// PanicError is the error of a job that panicked, so a single bad input
+// doesn't bring the whole pool down.
+type PanicError struct {
+	Value any    // Value passed to panic
+	Stack []byte // Stack trace of the panic
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("job panicked: %v", e.Value)
+}
+
+// ErrPoolClosed is returned when submitting to a WorkerPool being shut down.
+var ErrPoolClosed = errors.New("worker pool closed")
+
+// PoolOption customizes the behaviour of a WorkerPool.
+type PoolOption func(*poolConfig)
+
+type poolConfig struct {
+	ordered bool
+	window  int
+	buffer  int
+}
+
+// WithOrderedResults makes the pool emit the results in the order their
+// inputs were submitted, rather than as soon as they are ready. The results
+// finished ahead of their turn are held back, see WithReorderWindow.
+func WithOrderedResults() PoolOption {
+	return func(cfg *poolConfig) {
+		cfg.ordered = true
+	}
+}
+
+// WithReorderWindow bounds how many submitted inputs can be in flight or have
+// their result held back with WithOrderedResults, so a stuck job doesn't make
+// the pool buffer every later result: the workers stall once they are `n`
+// inputs ahead of it, see OrderedMap. Defaults to twice the workers.
+func WithReorderWindow(n int) PoolOption {
+	return func(cfg *poolConfig) {
+		cfg.window = n
+	}
+}
+
+// WithResultsBuffer sets the capacity of the results channel.
+func WithResultsBuffer(n int) PoolOption {
+	return func(cfg *poolConfig) {
+		cfg.buffer = n
+	}
+}
+
+// WorkerPool processes the submitted inputs with a bounded number of workers.
+// It's the combination of Split, fanning the inputs out to the workers, and
+// Funnel, fanning their results back in.
+type WorkerPool[In, Out any] struct {
+	ctx     context.Context
+	jobs    chan poolJob[In]
+	results <-chan JobResult[In, Out]
+
+	mu      sync.Mutex // Serializes the submissions so indexes follow their order
+	next    int
+	closed  bool
+	closing chan struct{}
+	once    sync.Once
+	done    chan struct{} // Closed once every result was emitted
+}
+
+type poolJob[In any] struct {
+	index int
+	input In
+}
+
+// NewWorkerPool starts `workers` workers, at least one, running `job`.
+// Canceling ctx stops the pool right away, abandoning the jobs not yet run.
+func NewWorkerPool[In, Out any](ctx context.Context, workers int, job Job[In, Out], opts ...PoolOption) *WorkerPool[In, Out] {
+	var cfg poolConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	workers = max(1, workers)
+
+	p := &WorkerPool[In, Out]{
+		ctx:     ctx,
+		jobs:    make(chan poolJob[In]),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	var results <-chan JobResult[In, Out]
+	if cfg.ordered {
+		if cfg.window <= 0 {
+			cfg.window = 2 * workers
+		}
+		results = orderedJobs(ctx, p.jobs, workers, cfg.window, job)
+	} else {
+		outs := make([]<-chan JobResult[In, Out], 0, workers)
+		for _, in := range Split(ctx, p.jobs, workers) {
+			out := make(chan JobResult[In, Out])
+			outs = append(outs, out)
+
+			go func(in <-chan poolJob[In]) { // Each worker runs the jobs it's handed
+				defer close(out)
+
+				for j := range in {
+					if !send(ctx, out, runJob(ctx, job, j)) {
+						return
+					}
+				}
+			}(in)
+		}
+
+		results = Funnel(ctx, outs)
+	}
+
+	final := make(chan JobResult[In, Out], cfg.buffer)
+	go func() {
+		defer close(p.done)
+		defer close(final)
+
+		forward(ctx, results, final)
+	}()
+	p.results = final
+
+	return p
+}
+
+// Submit hands an input to the pool, blocking until a worker is free to take
+// it, ctx is canceled or the pool is shut down.
+func (p *WorkerPool[In, Out]) Submit(ctx context.Context, in In) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return ErrPoolClosed
+	}
+
+	select {
+	case p.jobs <- poolJob[In]{index: p.next, input: in}:
+		p.next++
+		return nil
+	case <-p.closing:
+		return ErrPoolClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.ctx.Done():
+		return p.ctx.Err()
+	}
+}
+
+// Results returns the channel the results are emitted on. It must be read
+// until closed, which happens once the pool is shut down and drained.
+func (p *WorkerPool[In, Out]) Results() <-chan JobResult[In, Out] {
+	return p.results
+}
+
+// Shutdown gracefully stops the pool: no more inputs are accepted, and it
+// waits for the submitted ones to be processed and their results emitted,
+// or for ctx to be canceled.
+func (p *WorkerPool[In, Out]) Shutdown(ctx context.Context) error {
+	p.once.Do(func() {
+		close(p.closing) // Get the blocked submissions out of the way
+
+		p.mu.Lock()
+		p.closed = true
+		close(p.jobs)
+		p.mu.Unlock()
+	})
+
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ProcessAll processes all the inputs with `workers` workers and returns their
+// results, in the order of the inputs.
+func ProcessAll[In, Out any](ctx context.Context, inputs []In, workers int, job Job[In, Out]) []JobResult[In, Out] {
+	pool := NewWorkerPool(ctx, workers, job)
+
+	go func() {
+		for _, in := range inputs {
+			if pool.Submit(ctx, in) != nil {
+				break
+			}
+		}
+		pool.Shutdown(ctx)
+	}()
+
+	results := make([]JobResult[In, Out], len(inputs))
+	for i, in := range inputs { // Inputs never run report why
+		results[i] = JobResult[In, Out]{Index: i, Input: in, Err: ErrPoolClosed}
+	}
+
+	for res := range pool.Results() {
+		results[res.Index] = res
+	}
+
+	if ctx.Err() != nil {
+		for i := range results {
+			if errors.Is(results[i].Err, ErrPoolClosed) {
+				results[i].Err = ctx.Err()
+			}
+		}
+	}
+
+	return results
+}
+
+// runJob runs the job for an input, recovering from its panics.
+func runJob[In, Out any](ctx context.Context, job Job[In, Out], j poolJob[In]) (res JobResult[In, Out]) {
+	res.Index, res.Input = j.index, j.input
+
+	defer func() {
+		if r := recover(); r != nil {
+			res.Err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	res.Value, res.Err = job(ctx, j.input)
+
+	return res
+}
+
+// orderedJobs runs the jobs with OrderedMap, which emits the results in
+// submission order within a bounded window. Its results carry the poolJob
+// they were run for, so they are turned back into results of the input.
+func orderedJobs[In, Out any](ctx context.Context, jobs <-chan poolJob[In], workers, window int, job Job[In, Out]) <-chan JobResult[In, Out] {
+	run := func(ctx context.Context, j poolJob[In]) (Out, error) {
+		return job(ctx, j.input)
+	}
+
+	ordered := make(chan JobResult[In, Out])
+	go func() {
+		defer close(ordered)
+
+		for res := range OrderedMap(ctx, jobs, workers, window, run) {
+			if !send(ctx, ordered, JobResult[In, Out]{Index: res.Input.index, Input: res.Input.input, Value: res.Value, Err: res.Err}) {
+				return
+			}
+		}
+	}()
+
+	return ordered
+}